@@ -0,0 +1,4 @@
+#!/usr/bin/env bash
+
+# Runs the eval tests (including forked ones) with the compiling evaluator.
+./joker --compile tests/run-eval-tests.joke --compile "$@"
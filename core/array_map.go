@@ -163,6 +163,7 @@ func (m *ArrayMap) Set(key Object, value Object) {
 	if i != -1 {
 		m.arr[i+1] = value
 	} else {
+		chargeAlloc(allocEntry)
 		m.arr = append(m.arr, key)
 		m.arr = append(m.arr, value)
 	}
@@ -173,6 +174,7 @@ func (m *ArrayMap) Add(key Object, value Object) bool {
 	if i != -1 {
 		return false
 	}
+	chargeAlloc(allocEntry)
 	m.arr = append(m.arr, key)
 	m.arr = append(m.arr, value)
 	return true
@@ -183,6 +185,7 @@ func (m *ArrayMap) Plus(key Object, value Object) *ArrayMap {
 	if i != -1 {
 		return m
 	}
+	chargeAlloc(allocEntry)
 	m.arr = append(m.arr, key)
 	m.arr = append(m.arr, value)
 	return m
@@ -209,6 +212,7 @@ func (m *ArrayMap) Assoc(key Object, value Object) Associative {
 	if int64(len(m.arr)) >= HASHMAP_THRESHOLD {
 		return NewHashMap(m.arr...).Assoc(key, value)
 	}
+	chargeAlloc(allocEntry)
 	res := m.Clone()
 	res.arr = append(res.arr, key)
 	res.arr = append(res.arr, value)
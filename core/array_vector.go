@@ -37,12 +37,14 @@ func (v *ArrayVector) Conjoin(obj Object) Vec {
 		res.meta = v.meta
 		return res
 	}
+	chargeAlloc(allocElem)
 	res := v.Clone()
 	res.arr = append(res.arr, obj)
 	return res
 }
 
 func (v *ArrayVector) Append(obj Object) {
+	chargeAlloc(allocElem)
 	v.arr = append(v.arr, obj)
 }
 
@@ -183,6 +185,7 @@ func NewArrayVectorFrom(objs ...Object) *ArrayVector {
 	if n == 0 {
 		return EmptyArrayVector()
 	}
+	chargeAlloc(n * allocElem)
 	arr := make([]Object, n)
 	for i, o := range objs {
 		arr[i] = o
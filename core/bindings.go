@@ -0,0 +1,114 @@
+package core
+
+type (
+	// bindingFrame holds the values vars had before
+	// they were bound by push-thread-bindings.
+	bindingFrame map[*Var]Object
+
+	// threadBinding is a var bound by the goroutine owning a runtime.
+	// Thread bindings are stored in the vars themselves, like root values,
+	// and are swapped out when the goroutine releases the GIL: while
+	// the goroutine holds the GIL, the var holds the goroutine's value
+	// and other holds the root value; otherwise, it's the other way around.
+	threadBinding struct {
+		other Object
+		depth int
+	}
+)
+
+func (rt *Runtime) swapBindings() {
+	for v, b := range rt.bound {
+		v.Value, b.other = b.other, v.Value
+	}
+}
+
+// conveyBindings returns the thread bindings of rt for a new goroutine
+// that hasn't acquired the GIL yet. It only reads fields of rt
+// that don't change when rt doesn't hold the GIL.
+func (rt *Runtime) conveyBindings() map[*Var]*threadBinding {
+	if len(rt.bound) == 0 {
+		return nil
+	}
+	res := make(map[*Var]*threadBinding, len(rt.bound))
+	for v, b := range rt.bound {
+		value := b.other
+		if rt.held {
+			value = v.Value
+		}
+		res[v] = &threadBinding{other: value, depth: 1}
+	}
+	return res
+}
+
+func (rt *Runtime) pushThreadBindings(bindings Map) {
+	frame := bindingFrame{}
+	for iter := bindings.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		v := EnsureObjectIsVar(p.Key, "Binding key must be a Var, got %s")
+		if ns, ok := p.Value.(*Namespace); ok && v == GLOBAL_ENV.ns {
+			rt.checkNamespaceOwner(ns)
+		}
+		frame[v] = v.Value
+		if b, ok := rt.bound[v]; ok {
+			b.depth++
+		} else {
+			if rt.bound == nil {
+				rt.bound = make(map[*Var]*threadBinding)
+			}
+			rt.bound[v] = &threadBinding{other: v.Value, depth: 1}
+		}
+		v.Value = p.Value
+	}
+	rt.bindings = append(rt.bindings, frame)
+}
+
+func (rt *Runtime) popThreadBindings() {
+	n := len(rt.bindings)
+	if n == 0 {
+		panic(rt.NewError("Pop without matching push"))
+	}
+	frame := rt.bindings[n-1]
+	rt.bindings = rt.bindings[:n-1]
+	for v, prev := range frame {
+		b := rt.bound[v]
+		b.depth--
+		if b.depth == 0 {
+			v.Value = b.other
+			delete(rt.bound, v)
+		} else {
+			v.Value = prev
+		}
+	}
+}
+
+// setVarRoot sets the root value of v. If v is bound by the goroutine
+// owning rt, the root value is in the thread binding rather than in v.
+// rt must hold the GIL.
+func (rt *Runtime) setVarRoot(v *Var, val Object) {
+	rt.checkNamespaceOwner(v.ns)
+	if b, ok := rt.bound[v]; ok {
+		b.other = val
+	} else {
+		v.Value = val
+	}
+}
+
+// PushThreadBindings binds the vars in bindings (a map of vars
+// to values) for the goroutine owning rt, like push-thread-bindings.
+// rt must hold the GIL.
+func (rt *Runtime) PushThreadBindings(bindings Map) {
+	rt.pushThreadBindings(bindings)
+}
+
+// PopThreadBindings undoes the last PushThreadBindings.
+func (rt *Runtime) PopThreadBindings() {
+	rt.popThreadBindings()
+}
+
+func (rt *Runtime) threadBindings() Map {
+	res := EmptyArrayMap()
+	for v := range rt.bound {
+		res.Add(v, v.Value)
+	}
+	return res
+}
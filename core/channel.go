@@ -1,6 +1,9 @@
 package core
 
 import (
+	"math/rand"
+	"reflect"
+	"time"
 	"unsafe"
 )
 
@@ -14,6 +17,19 @@ type (
 		isClosed bool
 		hash     uint32
 	}
+	// Future runs a callable in a goroutine. done is closed
+	// when the result is available or the future is cancelled.
+	Future struct {
+		done        chan struct{}
+		result      FutureResult
+		isCancelled bool
+	}
+	// Promise holds a value that can be delivered only once.
+	// done is closed on delivery.
+	Promise struct {
+		done  chan struct{}
+		value Object
+	}
 )
 
 func MakeFutureResult(value Object, err Error) FutureResult {
@@ -60,3 +76,369 @@ func (ch *Channel) Close() {
 		ch.isClosed = true
 	}
 }
+
+// MakeTimeoutChannel returns a channel that closes after d.
+func MakeTimeoutChannel(d time.Duration) *Channel {
+	ch := MakeChannel(make(chan FutureResult))
+	gil := RT.GIL
+	time.AfterFunc(d, func() {
+		gil.Lock()
+		defer gil.Unlock()
+		ch.Close()
+	})
+	return ch
+}
+
+// ChannelOp is a single channel operation: a take from ch
+// if value is nil, or a put of value onto ch otherwise.
+type ChannelOp struct {
+	ch    *Channel
+	value Object
+}
+
+func MakeTakeOp(ch *Channel) ChannelOp {
+	return ChannelOp{ch: ch}
+}
+
+func MakePutOp(ch *Channel, value Object) ChannelOp {
+	if value.Equals(NIL) {
+		panic(RT.NewError("Can't put nil on channel"))
+	}
+	return ChannelOp{ch: ch, value: value}
+}
+
+func (op ChannelOp) isPut() bool {
+	return op.value != nil
+}
+
+func (op ChannelOp) selectCase() reflect.SelectCase {
+	if op.isPut() {
+		return reflect.SelectCase{
+			Dir:  reflect.SelectSend,
+			Chan: reflect.ValueOf(op.ch.ch),
+			Send: reflect.ValueOf(MakeFutureResult(op.value, nil)),
+		}
+	}
+	return reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(op.ch.ch)}
+}
+
+// result returns the result of the completed operation: true for puts,
+// the value taken (or nil if the channel is closed) for takes.
+func (op ChannelOp) result(recv reflect.Value, ok bool) Object {
+	if op.isPut() {
+		return MakeBoolean(true)
+	}
+	if !ok {
+		return NIL
+	}
+	res := recv.Interface().(FutureResult)
+	if res.err != nil {
+		panic(res.err)
+	}
+	return res.value
+}
+
+// selectOps performs one of the ready operations. If none is ready,
+// it blocks unless nonBlocking is true, in which case chosen is -1.
+// chosen is -1 too if done is closed before any operation completes.
+// Putting onto a closed channel panics, which is reported as putClosed.
+func selectOps(ops []ChannelOp, nonBlocking bool, done <-chan struct{}) (chosen int, recv reflect.Value, ok bool, putClosed bool) {
+	cases := make([]reflect.SelectCase, len(ops), len(ops)+1)
+	for i, op := range ops {
+		cases[i] = op.selectCase()
+	}
+	if nonBlocking {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	} else if done != nil {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)})
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			putClosed = true
+		}
+	}()
+	chosen, recv, ok = reflect.Select(cases)
+	if chosen == len(ops) {
+		chosen = -1
+	}
+	return
+}
+
+// closedPut returns the first put onto a closed channel, or -1.
+func closedPut(ops []ChannelOp) int {
+	for i, op := range ops {
+		if op.isPut() && op.ch.isClosed {
+			return i
+		}
+	}
+	return -1
+}
+
+// TryChannelOp performs op if it can complete immediately.
+// Returns nil if it can't. Puts onto closed channels return false.
+func TryChannelOp(op ChannelOp) Object {
+	ops := []ChannelOp{op}
+	if closedPut(ops) == 0 {
+		return MakeBoolean(false)
+	}
+	chosen, recv, ok, putClosed := selectOps(ops, true, nil)
+	if putClosed {
+		return MakeBoolean(false)
+	}
+	if chosen < 0 {
+		return nil
+	}
+	return op.result(recv, ok)
+}
+
+// Alts completes at most one of ops and returns the vector [result ch].
+// Operations that are ready are tried first, in order if priority is true
+// and in random order otherwise. If none is ready, returns
+// [defaultValue :default] if defaultValue is not nil, or blocks
+// (releasing the GIL) until one of ops completes.
+func Alts(ops []ChannelOp, priority bool, defaultValue Object) Object {
+	if len(ops) == 0 {
+		panic(RT.NewError("alts! must have at least one channel operation"))
+	}
+	order := rand.Perm(len(ops))
+	if priority {
+		for i := range order {
+			order[i] = i
+		}
+	}
+	for _, i := range order {
+		if res := TryChannelOp(ops[i]); res != nil {
+			return NewArrayVectorFrom(res, ops[i].ch)
+		}
+	}
+	if defaultValue != nil {
+		return NewArrayVectorFrom(defaultValue, KEYWORDS._default)
+	}
+	rt := RT.ReleaseGIL()
+	chosen, recv, ok, putClosed := selectOps(ops, false, rt.limitsDone())
+	rt.AcquireGIL()
+	if chosen < 0 && !putClosed {
+		rt.checkLimits()
+	}
+	if putClosed {
+		chosen = closedPut(ops)
+		return NewArrayVectorFrom(MakeBoolean(false), ops[chosen].ch)
+	}
+	return NewArrayVectorFrom(ops[chosen].result(recv, ok), ops[chosen].ch)
+}
+
+func isClosed(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitFor blocks until done is closed, releasing the GIL while waiting
+// so that other goroutines can run. A negative timeout means waiting forever.
+// Returns false if the timeout expired first.
+func waitFor(done chan struct{}, timeout time.Duration) bool {
+	if isClosed(done) {
+		return true
+	}
+	rt := RT.ReleaseGIL()
+	interrupted := false
+	defer func() {
+		rt.AcquireGIL()
+		if interrupted {
+			rt.checkLimits()
+		}
+	}()
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-done:
+		return true
+	case <-expired:
+		return false
+	case <-rt.limitsDone():
+		interrupted = true
+		return false
+	}
+}
+
+func MakeFuture(f Callable) *Future {
+	res := &Future{done: make(chan struct{})}
+	rt := RT.Spawn()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				switch r := r.(type) {
+				case Error:
+					res.complete(MakeFutureResult(NIL, r))
+				default:
+					rt.ReleaseGIL()
+					panic(r)
+				}
+			}
+			rt.ReleaseGIL()
+		}()
+
+		rt.AcquireGIL()
+		res.complete(MakeFutureResult(f.Call([]Object{}), nil))
+	}()
+	return res
+}
+
+// complete is called with the GIL held. The result of
+// a cancelled future is discarded.
+func (f *Future) complete(result FutureResult) {
+	if f.isCancelled {
+		return
+	}
+	f.result = result
+	close(f.done)
+}
+
+// Cancel marks the future as cancelled unless it has already completed.
+// Go has no way to stop a goroutine, so the computation itself keeps
+// running, but its result is never made available.
+func (f *Future) Cancel() bool {
+	if isClosed(f.done) {
+		return false
+	}
+	f.isCancelled = true
+	close(f.done)
+	return true
+}
+
+func (f *Future) IsCancelled() bool {
+	return f.isCancelled
+}
+
+func (f *Future) IsRealized() bool {
+	return isClosed(f.done)
+}
+
+func (f *Future) value() Object {
+	if f.isCancelled {
+		panic(RT.NewError("Future was cancelled"))
+	}
+	if f.result.err != nil {
+		panic(f.result.err)
+	}
+	return f.result.value
+}
+
+func (f *Future) Deref() Object {
+	waitFor(f.done, -1)
+	return f.value()
+}
+
+func (f *Future) DerefWithTimeout(timeout time.Duration, timeoutValue Object) Object {
+	if !waitFor(f.done, timeout) {
+		return timeoutValue
+	}
+	return f.value()
+}
+
+func (f *Future) ToString(escape bool) string {
+	switch {
+	case f.isCancelled:
+		return "#object[Future {:status :cancelled}]"
+	case !isClosed(f.done):
+		return "#object[Future {:status :pending}]"
+	case f.result.err != nil:
+		return "#object[Future {:status :failed}]"
+	default:
+		return "#object[Future {:status :ready, :val " + f.result.value.ToString(escape) + "}]"
+	}
+}
+
+func (f *Future) Equals(other interface{}) bool {
+	return f == other
+}
+
+func (f *Future) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (f *Future) GetType() *Type {
+	return TYPE.Future
+}
+
+func (f *Future) Hash() uint32 {
+	return HashPtr(uintptr(unsafe.Pointer(f)))
+}
+
+func (f *Future) WithInfo(info *ObjectInfo) Object {
+	return f
+}
+
+func MakePromise() *Promise {
+	return &Promise{done: make(chan struct{})}
+}
+
+// Deliver sets the value of the promise and releases all pending derefs.
+// Returns false if the promise has already been delivered.
+func (p *Promise) Deliver(value Object) bool {
+	if isClosed(p.done) {
+		return false
+	}
+	p.value = value
+	close(p.done)
+	return true
+}
+
+func (p *Promise) IsRealized() bool {
+	return isClosed(p.done)
+}
+
+func (p *Promise) Deref() Object {
+	waitFor(p.done, -1)
+	return p.value
+}
+
+func (p *Promise) DerefWithTimeout(timeout time.Duration, timeoutValue Object) Object {
+	if !waitFor(p.done, timeout) {
+		return timeoutValue
+	}
+	return p.value
+}
+
+// Calling a promise with a value delivers it, like deliver does.
+func (p *Promise) Call(args []Object) Object {
+	CheckArity(args, 1, 1)
+	if p.Deliver(args[0]) {
+		return p
+	}
+	return NIL
+}
+
+func (p *Promise) ToString(escape bool) string {
+	if isClosed(p.done) {
+		return "#object[Promise {:status :ready, :val " + p.value.ToString(escape) + "}]"
+	}
+	return "#object[Promise {:status :pending}]"
+}
+
+func (p *Promise) Equals(other interface{}) bool {
+	return p == other
+}
+
+func (p *Promise) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (p *Promise) GetType() *Type {
+	return TYPE.Promise
+}
+
+func (p *Promise) Hash() uint32 {
+	return HashPtr(uintptr(unsafe.Pointer(p)))
+}
+
+func (p *Promise) WithInfo(info *ObjectInfo) Object {
+	return p
+}
@@ -0,0 +1,342 @@
+package core
+
+type (
+	// compiledExpr is an Expr compiled into a Go closure.
+	// Positions of local bindings and vars referenced by the Expr
+	// are resolved at compile time, and evaluating it doesn't
+	// go through Eval, except for the kinds of Exprs
+	// the compiler doesn't handle (see compiler.compile).
+	compiledExpr func(env *LocalEnv) Object
+
+	compiler struct {
+		// Frame number of the local environment the Expr
+		// being compiled is evaluated in, -1 at top level.
+		frame int
+	}
+)
+
+// COMPILE_MODE makes functions and top-level forms evaluated by TryEval
+// and eval run compiled into Go closures instead of interpreting
+// their Expr trees (see --compile command line option).
+var COMPILE_MODE bool = false
+
+// evalTopLevel evaluates a top-level form (env is nil).
+func evalTopLevel(expr Expr) Object {
+	if !COMPILE_MODE {
+		return Eval(expr, nil)
+	}
+	parentExpr := RT.currentExpr
+	RT.currentExpr = expr
+	defer (func() { RT.currentExpr = parentExpr })()
+	c := compiler{frame: -1}
+	return c.compile(expr)(nil)
+}
+
+// evalFnBody evaluates the body of a function arity in env,
+// the frame of which holds the arguments. In COMPILE_MODE,
+// the body is compiled on the first call; the frame number of env
+// doesn't change between calls, as frames are lexical.
+func (arity *FnArityExpr) evalFnBody(env *LocalEnv) Object {
+	if !COMPILE_MODE {
+		return evalTailLoop(arity.body, env)
+	}
+	if arity.compiled == nil {
+		c := compiler{frame: env.frame}
+		arity.compiled = c.compileLoop(arity.body, true)
+	}
+	return arity.compiled(env)
+}
+
+func (c *compiler) withFrame(f func()) {
+	c.frame++
+	f()
+	c.frame--
+}
+
+func (c *compiler) compileSeq(exprs []Expr) []compiledExpr {
+	res := make([]compiledExpr, len(exprs))
+	for i, expr := range exprs {
+		res[i] = c.compile(expr)
+	}
+	return res
+}
+
+func evalCompiledSeq(exprs []compiledExpr, env *LocalEnv) []Object {
+	res := make([]Object, len(exprs))
+	for i, expr := range exprs {
+		res[i] = expr(env)
+	}
+	return res
+}
+
+// compileBody compiles body; tail is true if it's in tail position
+// of a function body (see TailCall).
+func (c *compiler) compileBody(body []Expr, tail bool) compiledExpr {
+	compileLast := c.compile
+	if tail {
+		compileLast = c.compileTail
+	}
+	switch len(body) {
+	case 0:
+		return func(env *LocalEnv) Object {
+			return NIL
+		}
+	case 1:
+		return compileLast(body[0])
+	}
+	last := len(body) - 1
+	exprs := append(c.compileSeq(body[:last]), compileLast(body[last]))
+	return func(env *LocalEnv) Object {
+		last := len(exprs) - 1
+		for _, expr := range exprs[:last] {
+			expr(env)
+		}
+		return exprs[last](env)
+	}
+}
+
+func (c *compiler) compileLoop(body []Expr, tail bool) compiledExpr {
+	b := c.compileBody(body, tail)
+	return func(env *LocalEnv) Object {
+		for {
+			res := b(env)
+			if rb, ok := res.(RecurBindings); ok {
+				RT.checkLimits()
+				env = env.replaceFrame(rb)
+				continue
+			}
+			return res
+		}
+	}
+}
+
+func (c *compiler) compileBinding(expr *BindingExpr) compiledExpr {
+	hops := c.frame - expr.binding.frame
+	index := expr.binding.index
+	switch hops {
+	case 0:
+		return func(env *LocalEnv) Object {
+			return env.bindings[index]
+		}
+	case 1:
+		return func(env *LocalEnv) Object {
+			return env.parent.bindings[index]
+		}
+	}
+	return func(env *LocalEnv) Object {
+		for i := 0; i < hops; i++ {
+			env = env.parent
+		}
+		return env.bindings[index]
+	}
+}
+
+func (c *compiler) compileCall(expr *CallExpr) compiledExpr {
+	callable := c.compile(expr.callable)
+	args := c.compileSeq(expr.args)
+	return func(env *LocalEnv) Object {
+		return callCompiled(expr, callable(env), args, env)
+	}
+}
+
+// compileTailCall compiles a call in tail position of a function body.
+// Calls of Fns evaluate to TailCalls instead of being made.
+func (c *compiler) compileTailCall(expr *CallExpr) compiledExpr {
+	callable := c.compile(expr.callable)
+	args := c.compileSeq(expr.args)
+	return func(env *LocalEnv) Object {
+		obj := callable(env)
+		if fn, ok := obj.(*Fn); ok {
+			return &TailCall{fn: fn, args: evalCompiledSeq(args, env), expr: expr}
+		}
+		return callCompiled(expr, obj, args, env)
+	}
+}
+
+func callCompiled(expr *CallExpr, obj Object, args []compiledExpr, env *LocalEnv) Object {
+	f, ok := obj.(Callable)
+	if !ok {
+		panic(RT.NewErrorWithPos(obj.ToString(false)+" is not a Fn", expr.callable.Pos()))
+	}
+	rt := RT
+	rt.checkLimits()
+	vals := evalCompiledSeq(args, env)
+	parentExpr := rt.currentExpr
+	rt.currentExpr = expr
+	defer func() { rt.currentExpr = parentExpr }()
+	return f.Call(vals)
+}
+
+// compileTail compiles expr in tail position of a function body.
+func (c *compiler) compileTail(expr Expr) compiledExpr {
+	switch expr := expr.(type) {
+	case *CallExpr:
+		return c.compileTailCall(expr)
+	case *IfExpr:
+		return c.compileIf(expr, true)
+	case *DoExpr:
+		return c.compileBody(expr.body, true)
+	case *LetExpr:
+		return c.compileLet(expr, true)
+	case *LoopExpr:
+		return c.compileLoopExpr(expr, true)
+	default:
+		return c.compile(expr)
+	}
+}
+
+func (c *compiler) compileIf(expr *IfExpr, tail bool) compiledExpr {
+	cond := c.compile(expr.cond)
+	var positive, negative compiledExpr
+	if tail {
+		positive = c.compileTail(expr.positive)
+		negative = c.compileTail(expr.negative)
+	} else {
+		positive = c.compile(expr.positive)
+		negative = c.compile(expr.negative)
+	}
+	return func(env *LocalEnv) Object {
+		if ToBool(cond(env)) {
+			return positive(env)
+		}
+		return negative(env)
+	}
+}
+
+func (c *compiler) compileLet(expr *LetExpr, tail bool) compiledExpr {
+	var values []compiledExpr
+	var body compiledExpr
+	c.withFrame(func() {
+		values = c.compileSeq(expr.values)
+		body = c.compileBody(expr.body, tail)
+	})
+	n := len(expr.names)
+	return func(env *LocalEnv) Object {
+		env = env.addEmptyFrame(n)
+		for _, value := range values {
+			env.addBinding(value(env))
+		}
+		return body(env)
+	}
+}
+
+func (c *compiler) compileLoopExpr(expr *LoopExpr, tail bool) compiledExpr {
+	var values []compiledExpr
+	var body compiledExpr
+	c.withFrame(func() {
+		values = c.compileSeq(expr.values)
+		body = c.compileLoop(expr.body, tail)
+	})
+	n := len(expr.names)
+	return func(env *LocalEnv) Object {
+		env = env.addEmptyFrame(n)
+		for _, value := range values {
+			env.addBinding(value(env))
+		}
+		return body(env)
+	}
+}
+
+func (c *compiler) compileTry(expr *TryExpr) compiledExpr {
+	body := c.compileBody(expr.body, false)
+	var finally compiledExpr
+	if expr.finallyExpr != nil {
+		finally = c.compileBody(expr.finallyExpr, false)
+	}
+	catches := make([]compiledExpr, len(expr.catches))
+	c.withFrame(func() {
+		for i, catchExpr := range expr.catches {
+			catches[i] = c.compileBody(catchExpr.body, false)
+		}
+	})
+	return func(env *LocalEnv) (obj Object) {
+		rt := RT
+		parentExpr := rt.currentExpr
+		defer func() {
+			defer func() {
+				if finally != nil {
+					rt.currentExpr = parentExpr
+					finally(env)
+				}
+			}()
+			if r := recover(); r != nil {
+				switch r := r.(type) {
+				case Error:
+					for i, catchExpr := range expr.catches {
+						if IsInstance(catchExpr.excType, r) {
+							rt.currentExpr = parentExpr
+							obj = catches[i](env.addFrame([]Object{r}))
+							return
+						}
+					}
+					panic(r)
+				default:
+					panic(r)
+				}
+			}
+		}()
+		return body(env)
+	}
+}
+
+// compile compiles expr. Exprs that are rare or not performance
+// critical are evaluated with Eval.
+func (c *compiler) compile(expr Expr) compiledExpr {
+	switch expr := expr.(type) {
+	case *LiteralExpr:
+		obj := expr.obj
+		return func(env *LocalEnv) Object {
+			return obj
+		}
+	case *VarRefExpr:
+		vr := expr.vr
+		return func(env *LocalEnv) Object {
+			return vr.Resolve()
+		}
+	case *BindingExpr:
+		return c.compileBinding(expr)
+	case *CallExpr:
+		return c.compileCall(expr)
+	case *IfExpr:
+		return c.compileIf(expr, false)
+	case *DoExpr:
+		return c.compileBody(expr.body, false)
+	case *LetExpr:
+		return c.compileLet(expr, false)
+	case *LoopExpr:
+		return c.compileLoopExpr(expr, false)
+	case *RecurExpr:
+		args := c.compileSeq(expr.args)
+		return func(env *LocalEnv) Object {
+			return RecurBindings(evalCompiledSeq(args, env))
+		}
+	case *VectorExpr:
+		elements := c.compileSeq(expr.v)
+		if len(elements) == 0 {
+			return func(env *LocalEnv) Object {
+				return EmptyArrayVector()
+			}
+		}
+		return func(env *LocalEnv) Object {
+			return &ArrayVector{arr: evalCompiledSeq(elements, env)}
+		}
+	case *FnExpr:
+		return expr.Eval
+	case *ThrowExpr:
+		e := c.compile(expr.e)
+		return func(env *LocalEnv) Object {
+			obj := e(env)
+			if err, ok := obj.(Error); ok {
+				panic(err)
+			}
+			RT.currentExpr = expr
+			panic(RT.NewError("Cannot throw " + obj.ToString(false)))
+		}
+	case *TryExpr:
+		return c.compileTry(expr)
+	default:
+		return func(env *LocalEnv) Object {
+			return Eval(expr, env)
+		}
+	}
+}
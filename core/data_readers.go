@@ -0,0 +1,178 @@
+package core
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	instantRegex = regexp.MustCompile(`^(\d\d\d\d)(?:-(\d\d)(?:-(\d\d)(?:[Tt](\d\d)(?::(\d\d)(?::(\d\d)(?:[.](\d+))?)?)?)?)?)?(?:[Zz]|([-+])(\d\d):(\d\d))?$`)
+	uuidRegex    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	// Names of the files, looked up in every *classpath* root,
+	// that map reader tags to symbols naming reader vars.
+	dataReadersFiles = []string{"data_readers.joke", "data_readers.cljc", "data_readers.clj"}
+
+	// Maps loaded from data readers files, keyed by filename.
+	// nil means there is no such file.
+	dataReadersCache = map[string]Map{}
+)
+
+func instantField(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// parseInstant parses RFC3339 timestamps, allowing any trailing
+// part of them (starting with month) to be omitted.
+func parseInstant(s string) (time.Time, bool) {
+	m := instantRegex.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	year := instantField(m[1], 0)
+	month := instantField(m[2], 1)
+	day := instantField(m[3], 1)
+	hour := instantField(m[4], 0)
+	minute := instantField(m[5], 0)
+	sec := instantField(m[6], 0)
+	nsec := 0
+	if frac := m[7]; frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		nsec = instantField(frac+strings.Repeat("0", 9-len(frac)), 0)
+	}
+	loc := time.UTC
+	if m[8] != "" {
+		offset := instantField(m[9], 0)*3600 + instantField(m[10], 0)*60
+		if offset >= 24*3600 || m[10] >= "60" {
+			return time.Time{}, false
+		}
+		if m[8] == "-" {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+	t := time.Date(year, time.Month(month), day, hour, minute, sec, nsec, loc)
+	// time.Date normalizes out of range values, e.g. February 30 becomes
+	// March 2, so check that nothing had to be normalized.
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day ||
+		t.Hour() != hour || t.Minute() != minute || t.Second() != sec {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func readInstant(s string) Time {
+	t, ok := parseInstant(s)
+	if !ok {
+		panic(RT.NewError("Unrecognized date/time syntax: " + s))
+	}
+	return MakeTime(t)
+}
+
+func readUUID(s string) UUID {
+	if !uuidRegex.MatchString(s) {
+		panic(RT.NewError("Invalid UUID string: " + s))
+	}
+	return MakeUUID(strings.ToLower(s))
+}
+
+// coreDataReader returns the reader for tag from the map
+// held by the joker.core var with the given name, or nil.
+func coreDataReader(varName Symbol, tag Symbol) Object {
+	v, ok := GLOBAL_ENV.CoreNamespace.mappings[varName.name]
+	if !ok {
+		return nil
+	}
+	m, ok := v.Value.(Map)
+	if !ok {
+		return nil
+	}
+	if ok, f := m.Get(tag); ok {
+		return f
+	}
+	return nil
+}
+
+func loadDataReadersFile(filename string) Map {
+	if m, ok := dataReadersCache[filename]; ok {
+		return m
+	}
+	var res Map
+	// Files outside of the sandbox's paths are treated as missing.
+	err := sandboxPathError(filename)
+	var f *os.File
+	if err == nil {
+		f, err = os.Open(filename)
+	}
+	if err == nil {
+		defer f.Close()
+		reader := NewReader(bufio.NewReader(f), filename)
+		eatWhitespace(reader)
+		obj := readFirst(reader)
+		m, ok := obj.(Map)
+		if !ok {
+			panic(MakeReadError(reader, "Data readers file must contain a map, got "+obj.GetType().ToString(false)))
+		}
+		res = m
+	}
+	dataReadersCache[filename] = res
+	return res
+}
+
+// classPathDataReader looks tag up in the data readers files found
+// in *classpath* roots. An empty root stands for the root of
+// the current namespace, like it does for require.
+func classPathDataReader(tag Symbol) (Object, string) {
+	cp, ok := GLOBAL_ENV.classPath.Value.(Vec)
+	if !ok {
+		return nil, ""
+	}
+	for i := 0; i < cp.Count(); i++ {
+		root := EnsureObjectIsString(cp.At(i), "*classpath*["+strconv.Itoa(i)+"]: %s").S
+		if root == "" {
+			root = namespaceRoot()
+		}
+		for _, name := range dataReadersFiles {
+			filename := filepath.Join(root, name)
+			m := loadDataReadersFile(filename)
+			if m == nil {
+				continue
+			}
+			if ok, f := m.Get(tag); ok {
+				return f, filename
+			}
+		}
+	}
+	return nil, ""
+}
+
+// resolveDataReader turns a symbol from a data readers file into
+// the var it names, requiring the var's namespace if necessary.
+func resolveDataReader(reader *Reader, sym Symbol, filename string) *Var {
+	if sym.ns == nil {
+		panic(MakeReadError(reader, "Data reader "+sym.ToString(false)+" in "+filename+" must be namespace-qualified"))
+	}
+	nsSym := MakeSymbol(*sym.ns)
+	ns := GLOBAL_ENV.FindNamespace(nsSym)
+	if ns == nil {
+		GLOBAL_ENV.CoreNamespace.Resolve("require").Call([]Object{nsSym})
+		ns = GLOBAL_ENV.FindNamespace(nsSym)
+	}
+	if ns != nil {
+		if vr, ok := ns.mappings[sym.name]; ok {
+			return vr
+		}
+	}
+	panic(MakeReadError(reader, "Unable to resolve data reader "+sym.ToString(false)+" in "+filename))
+}
@@ -11,8 +11,16 @@ import (
 )
 
 func externalHttpSourceToPath(lib string, url string) (path string) {
-	home := HomeDir()
-	localBase := filepath.Join(home, ".jokerd", "deps", strings.SplitN(url, "//", 2)[1])
+	// Downloading would bypass both the denial of joker.http
+	// and the checks of the paths the lib is written to.
+	if SANDBOX != nil {
+		panic(RT.NewError("Loading " + lib + " from " + url + " is denied by sandbox policy"))
+	}
+	depsDir := filepath.Join(HomeDir(), ".jokerd", "deps")
+	localBase := filepath.Join(depsDir, strings.SplitN(url, "//", 2)[1])
+	if rel, err := filepath.Rel(depsDir, localBase); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		panic(RT.NewError("Invalid URL in ns-sources for " + lib + ": " + url))
+	}
 	libBase := filepath.Join(strings.Split(lib, ".")...) + ".joke"
 	libPath := filepath.Join(localBase, libBase)
 	libPathDir := filepath.Dir(libPath)
@@ -0,0 +1,325 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type (
+	// ednReader reads data in EDN format (https://github.com/edn-format/edn).
+	// Unlike the regular reader, it never evaluates, resolves or
+	// looks up anything outside of the options it was given.
+	ednReader struct {
+		reader    *Reader
+		readers   Map
+		defaultFn Callable
+		depth     int
+	}
+)
+
+// maxEDNDepth limits how deeply values read by ednReader can be nested,
+// so that untrusted input cannot overflow the Go stack, which is fatal.
+const maxEDNDepth = 10000
+
+func ednOption(opts Map, name string) (Object, bool) {
+	if opts == nil {
+		return nil, false
+	}
+	ok, v := opts.Get(MakeKeyword(name))
+	return v, ok && !v.Equals(NIL)
+}
+
+// ReadEDN reads a single EDN value from rd. Recognized opts are
+// :readers (map from tag symbols to reader functions), :default
+// (function called with tag and value when no reader is found for a tag)
+// and :eof (value to return at the end of input instead of throwing).
+func ReadEDN(rd io.RuneReader, opts Map) Object {
+	r := &ednReader{reader: NewReader(rd, "<>")}
+	depth := len(posStack)
+	defer func() {
+		posStack = posStack[:depth]
+		if r := recover(); r != nil {
+			if err, ok := r.(ReadError); ok {
+				PanicOnErr(err)
+			}
+			panic(r)
+		}
+	}()
+	if readers, ok := ednOption(opts, "readers"); ok {
+		r.readers = EnsureObjectIsMap(readers, ":readers must be a map, got %s")
+	}
+	if defaultFn, ok := ednOption(opts, "default"); ok {
+		r.defaultFn = EnsureObjectIsCallable(defaultFn, ":default must be a function, got %s")
+	}
+	r.eatWhitespace()
+	if r.reader.Peek() == EOF {
+		if opts != nil {
+			if ok, eof := opts.Get(MakeKeyword("eof")); ok {
+				return eof
+			}
+		}
+		panic(MakeReadError(r.reader, "EOF while reading"))
+	}
+	obj := r.read()
+	// Give back the delimiter the value was terminated with,
+	// so that it's available to the next read from rd.
+	if r.reader.rewind == 0 {
+		if rs, ok := rd.(io.RuneScanner); ok {
+			rs.UnreadRune()
+		}
+	}
+	return obj
+}
+
+func (r *ednReader) eatWhitespace() {
+	reader := r.reader
+	c := reader.Get()
+	for c != EOF {
+		if isWhitespace(c) {
+			c = reader.Get()
+			continue
+		}
+		if c == ';' {
+			for c != '\n' && c != EOF {
+				c = reader.Get()
+			}
+			c = reader.Get()
+			continue
+		}
+		if c == '#' && reader.Peek() == '_' {
+			reader.Get()
+			r.read()
+			c = reader.Get()
+			continue
+		}
+		reader.Unget()
+		break
+	}
+}
+
+func (r *ednReader) read() Object {
+	if r.depth >= maxEDNDepth {
+		panic(MakeReadError(r.reader, fmt.Sprintf("Values nested deeper than %d levels are not allowed in EDN", maxEDNDepth)))
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+	r.eatWhitespace()
+	c := r.reader.Get()
+	// Positions are pushed here and popped by MakeReadObject,
+	// which is not called for every kind of value.
+	depth := len(posStack)
+	pushPos(r.reader)
+	obj := r.readValue(c)
+	posStack = posStack[:depth]
+	return obj
+}
+
+func (r *ednReader) readValue(c rune) Object {
+	reader := r.reader
+	switch {
+	case c == '\\':
+		return readCharacter(reader)
+	case unicode.IsDigit(c):
+		reader.Unget()
+		return readNumber(reader)
+	case c == '-' || c == '+':
+		if unicode.IsDigit(reader.Peek()) {
+			reader.Unget()
+			return readNumber(reader)
+		}
+		return readIdent(reader, c)
+	case c == '"':
+		return readString(reader)
+	case c == '(':
+		list := EmptyList
+		objs := r.readUntil(')')
+		for i := len(objs) - 1; i >= 0; i-- {
+			list = list.conj(objs[i])
+		}
+		return list
+	case c == '[':
+		return NewArrayVectorFrom(r.readUntil(']')...)
+	case c == '{':
+		return makeReadMap(reader, r.readUntil('}'), "")
+	case c == '#':
+		return r.readDispatch()
+	case c == ':' && reader.Peek() == ':':
+		panic(MakeReadError(reader, "Auto-resolved keywords are not allowed in EDN"))
+	case c == '/' && isDelimiter(reader.Peek()):
+		return SYMBOLS.backslash
+	case c == EOF:
+		panic(MakeReadError(reader, "Unexpected end of file"))
+	case c == ')' || c == ']' || c == '}':
+		panic(MakeReadError(reader, "Unmatched delimiter: "+string(c)))
+	case c == '\'' || c == '`' || c == '~' || c == '@' || c == '^':
+		panic(MakeReadError(reader, "Invalid character in EDN: "+string(c)))
+	default:
+		return readIdent(reader, c)
+	}
+}
+
+func (r *ednReader) readUntil(end rune) []Object {
+	var objs []Object
+	r.eatWhitespace()
+	for r.reader.Peek() != end {
+		objs = append(objs, r.read())
+		r.eatWhitespace()
+	}
+	r.reader.Get()
+	return objs
+}
+
+func (r *ednReader) readDispatch() Object {
+	reader := r.reader
+	c := reader.Get()
+	switch {
+	case c == '{':
+		set := EmptySet()
+		for _, obj := range r.readUntil('}') {
+			if !set.Add(obj) {
+				panic(MakeReadError(reader, "Duplicate set element "+obj.ToString(false)))
+			}
+		}
+		return set
+	case c == '#':
+		obj := r.read()
+		if sym, ok := obj.(Symbol); ok {
+			if v, found := specials[sym.ToString(false)]; found {
+				return Double{D: v}
+			}
+		}
+		panic(MakeReadError(reader, "Unknown symbolic value: ##"+obj.ToString(false)))
+	case c == ':':
+		if reader.Peek() == ':' {
+			panic(MakeReadError(reader, "Auto-resolved namespaced maps are not allowed in EDN"))
+		}
+		sym, ok := r.read().(Symbol)
+		if !ok || sym.ns != nil {
+			panic(MakeReadError(reader, "Namespaced map must specify a valid namespace"))
+		}
+		r.eatWhitespace()
+		if reader.Get() != '{' {
+			panic(MakeReadError(reader, "Namespaced map must specify a map"))
+		}
+		return makeReadMap(reader, r.readUntil('}'), sym.Name())
+	case unicode.IsLetter(c):
+		reader.Unget()
+		tag, ok := r.read().(Symbol)
+		if !ok {
+			panic(MakeReadError(reader, "Reader tag must be a symbol"))
+		}
+		return r.readTagged(tag)
+	case c == EOF:
+		panic(MakeReadError(reader, "Unexpected end of file"))
+	default:
+		panic(MakeReadError(reader, "Invalid dispatch character in EDN: #"+string(c)))
+	}
+}
+
+func (r *ednReader) readTagged(tag Symbol) Object {
+	obj := r.read()
+	if r.readers != nil {
+		if ok, f := r.readers.Get(tag); ok {
+			return EnsureObjectIsCallable(f, "Data reader for tag "+tag.ToString(false)+" must be a function, got %s").Call([]Object{obj})
+		}
+	}
+	if f := coreDataReader(SYMBOLS.defaultDataReaders, tag); f != nil {
+		return EnsureObjectIsCallable(f, "Data reader for tag "+tag.ToString(false)+" must be a function, got %s").Call([]Object{obj})
+	}
+	if r.defaultFn != nil {
+		return r.defaultFn.Call([]Object{tag, obj})
+	}
+	panic(MakeReadError(r.reader, "No reader function for tag "+tag.ToString(false)))
+}
+
+// isEDNIdent checks that s, the printed form of a symbol or keyword,
+// reads back as obj.
+func isEDNIdent(s string, obj Object) (res bool) {
+	defer func() {
+		if recover() != nil {
+			res = false
+		}
+	}()
+	rd := strings.NewReader(s)
+	read := ReadEDN(rd, nil)
+	return rd.Len() == 0 && read.Equals(obj) && read.GetType() == obj.GetType()
+}
+
+func cannotWriteEDN(obj Object) *EvalError {
+	return RT.NewError("Cannot write " + obj.GetType().ToString(false) + " as EDN: " + obj.ToString(true))
+}
+
+func writeEDNSeq(b *bytes.Buffer, seq Seq, open, close string) {
+	b.WriteString(open)
+	for first := true; !seq.IsEmpty(); seq = seq.Rest() {
+		if !first {
+			b.WriteRune(' ')
+		}
+		first = false
+		writeEDN(b, seq.First())
+	}
+	b.WriteString(close)
+}
+
+func writeEDN(b *bytes.Buffer, obj Object) {
+	switch obj := obj.(type) {
+	case Nil, Boolean, Int, Double, *BigInt, *BigFloat, *Ratio, Char:
+		b.WriteString(obj.ToString(true))
+	case String:
+		b.WriteString(escapeString(obj.S))
+	case Keyword, Symbol:
+		s := obj.ToString(false)
+		if !isEDNIdent(s, obj) {
+			panic(cannotWriteEDN(obj))
+		}
+		b.WriteString(s)
+	case Time:
+		b.WriteString("#inst " + escapeString(obj.T.Format(time.RFC3339Nano)))
+	case UUID:
+		b.WriteString(obj.ToString(true))
+	case *Record:
+		panic(cannotWriteEDN(obj))
+	case Map:
+		b.WriteRune('{')
+		for iter, first := obj.Iter(), true; iter.HasNext(); first = false {
+			if !first {
+				b.WriteString(", ")
+			}
+			p := iter.Next()
+			writeEDN(b, p.Key)
+			b.WriteRune(' ')
+			writeEDN(b, p.Value)
+		}
+		b.WriteRune('}')
+	case Set:
+		writeEDNSeq(b, obj.(Seqable).Seq(), "#{", "}")
+	case Vec:
+		writeEDNSeq(b, obj.Seq(), "[", "]")
+	case Seq:
+		writeEDNSeq(b, obj, "(", ")")
+	default:
+		panic(cannotWriteEDN(obj))
+	}
+}
+
+// WriteEDN writes obj to w in EDN format, so that reading
+// the output with ReadEDN produces a value equal to obj.
+// Values that cannot be represented in EDN, like functions or atoms,
+// cause an error, in which case nothing is written.
+func WriteEDN(w io.Writer, obj Object) {
+	var b bytes.Buffer
+	writeEDN(&b, obj)
+	_, err := w.Write(b.Bytes())
+	PanicOnErr(err)
+}
+
+// EDNString returns obj in EDN format. See WriteEDN.
+func EDNString(obj Object) string {
+	var b bytes.Buffer
+	writeEDN(&b, obj)
+	return b.String()
+}
@@ -23,6 +23,7 @@ type (
 		stdin         *Var
 		stderr        *Var
 		printReadably *Var
+		maxStackDepth *Var
 		file          *Var
 		MainFile      *Var
 		args          *Var
@@ -38,6 +38,13 @@ func NewEnv() *Env {
 	res.classPath.isPrivate = true
 	res.printReadably = res.CoreNamespace.Intern(MakeSymbol("*print-readably*"))
 	res.printReadably.Value = Boolean{B: true}
+	res.maxStackDepth = res.CoreNamespace.InternVar("*max-stack-depth*", Int{I: 100000},
+		MakeMeta(nil, `The maximum depth of the call stack. Calls that would make it deeper
+  throw StackOverflow. Calls in tail position of a function body
+  don't make the call stack deeper. When not an Int, depth is not limited.
+
+  Defaults to 100000`, "1.8"))
+	res.maxStackDepth.isDynamic = true
 	res.CoreNamespace.InternVar("*repl*", Boolean{B: false},
 		MakeMeta(nil, "true if Joker is running in repl mode", "1.5"))
 	res.CoreNamespace.InternVar("*linter-mode*", Boolean{B: LINTER_MODE},
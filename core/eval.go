@@ -18,30 +18,107 @@ type (
 		rt   *Runtime
 		hash uint32
 	}
+	// StackOverflow is raised when the call stack
+	// gets deeper than *max-stack-depth*.
+	StackOverflow struct {
+		*EvalError
+	}
 	Frame struct {
 		traceable Traceable
 	}
+	// tailCallFrame replaces the frame of a function that made a tail call.
+	// It keeps the position of the call it replaces, which is
+	// where its caller is, and has the name of the function called.
+	tailCallFrame struct {
+		pos  Position
+		expr *CallExpr
+	}
 	Callstack struct {
 		frames []Frame
 	}
+	// Runtime is the state of a goroutine running Joker code.
+	// Only the goroutine holding the GIL runs Joker code,
+	// and RT points to its runtime. Namespaces, var roots,
+	// lazy seqs, delays and atoms rely on the GIL rather than
+	// on locks of their own (see DEVELOPER.md).
 	Runtime struct {
 		callstack   *Callstack
 		currentExpr Expr
-		GIL         sync.Mutex
+		GIL         *sync.Mutex
+		held        bool
+		bindings    []bindingFrame
+		bound       map[*Var]*threadBinding
+		limits      *evalLimits
+		// Interpreter the runtime belongs to (see Isolate).
+		owner *Runtime
 	}
 )
 
+var gil = &sync.Mutex{}
+
 var RT *Runtime = &Runtime{
 	callstack: &Callstack{frames: make([]Frame, 0, 50)},
+	GIL:       gil,
 }
 
 func (rt *Runtime) clone() *Runtime {
 	return &Runtime{
 		callstack:   rt.callstack.clone(),
 		currentExpr: rt.currentExpr,
+		GIL:         rt.GIL,
+	}
+}
+
+// Spawn returns the runtime for a new goroutine started by
+// the current expression of rt. It has its own call stack,
+// but shares the GIL with rt.
+// The new goroutine starts with the thread bindings of rt
+// (see conveyBindings).
+func (rt *Runtime) Spawn() *Runtime {
+	return &Runtime{
+		callstack:   &Callstack{frames: make([]Frame, 0, 50)},
+		currentExpr: rt.currentExpr,
+		GIL:         rt.GIL,
+		bound:       rt.conveyBindings(),
+		limits:      rt.limits,
+		owner:       rt.owner,
 	}
 }
 
+// NewRuntime returns the runtime for a goroutine that runs Joker code
+// on its own, rather than being started by Joker code (see Spawn),
+// e.g. in a Go program embedding Joker.
+func NewRuntime() *Runtime {
+	return &Runtime{
+		callstack: &Callstack{frames: make([]Frame, 0, 50)},
+		GIL:       gil,
+	}
+}
+
+// AcquireGIL blocks until the GIL is available and makes rt
+// the current runtime.
+func (rt *Runtime) AcquireGIL() {
+	rt.GIL.Lock()
+	RT = rt
+	rt.held = true
+	rt.swapBindings()
+}
+
+// ReleaseGIL releases the GIL so that other goroutines can run
+// while the current one is blocked (e.g. on I/O). It returns the
+// current runtime, which must be used to reacquire the GIL, as RT
+// will likely point to the runtime of another goroutine by then:
+//
+//	rt := RT.ReleaseGIL()
+//	...
+//	rt.AcquireGIL()
+func (rt *Runtime) ReleaseGIL() *Runtime {
+	rt.swapBindings()
+	rt.held = false
+	rt.GIL.Unlock()
+	return rt
+}
+
 func (rt *Runtime) NewError(msg string) *EvalError {
 	res := &EvalError{
 		msg: msg,
@@ -53,8 +130,17 @@ func (rt *Runtime) NewError(msg string) *EvalError {
 	return res
 }
 
+// currentName returns the name of the function being called,
+// or "fn" if it's called from Go rather than by Joker code.
+func (rt *Runtime) currentName() string {
+	if tr, ok := rt.currentExpr.(Traceable); ok {
+		return tr.Name()
+	}
+	return "fn"
+}
+
 func (rt *Runtime) NewArgTypeError(index int, obj Object, expectedType string) *EvalError {
-	name := rt.currentExpr.(Traceable).Name()
+	name := rt.currentName()
 	return rt.NewError(fmt.Sprintf("Arg[%d] of %s must have type %s, got %s", index, name, expectedType, obj.GetType().ToString(false)))
 }
 
@@ -66,6 +152,13 @@ func (rt *Runtime) NewErrorWithPos(msg string, pos Position) *EvalError {
 	}
 }
 
+// Stacktraces of deep call stacks only show this many
+// outermost and innermost frames.
+const (
+	stacktraceHead = 10
+	stacktraceTail = 20
+)
+
 func (rt *Runtime) stacktrace() string {
 	b := getBuffer()
 	defer putBuffer(b)
@@ -74,19 +167,30 @@ func (rt *Runtime) stacktrace() string {
 		pos = rt.currentExpr.Pos()
 	}
 	name := "global"
-	for _, f := range rt.callstack.frames {
-		framePos := f.traceable.Pos()
-		b.WriteString(fmt.Sprintf("  %s %s:%d:%d\n", name, framePos.Filename(), framePos.startLine, framePos.startColumn))
-		name = f.traceable.Name()
-		if strings.HasPrefix(name, "#'") {
-			name = name[2:]
+	frames := rt.callstack.frames
+	for i := 0; i < len(frames); i++ {
+		if i == stacktraceHead && len(frames) > stacktraceHead+stacktraceTail {
+			omitted := len(frames) - stacktraceHead - stacktraceTail
+			b.WriteString(fmt.Sprintf("  ... %d frames omitted ...\n", omitted))
+			i += omitted
+			name = frames[i-1].name()
 		}
+		framePos := frames[i].traceable.Pos()
+		b.WriteString(fmt.Sprintf("  %s %s:%d:%d\n", name, framePos.Filename(), framePos.startLine, framePos.startColumn))
+		name = frames[i].name()
 	}
 	b.WriteString(fmt.Sprintf("  %s %s:%d:%d", name, pos.Filename(), pos.startLine, pos.startColumn))
 	return b.String()
 }
 
+func (f Frame) name() string {
+	return strings.TrimPrefix(f.traceable.Name(), "#'")
+}
+
 func (rt *Runtime) pushFrame() {
+	if depth, ok := GLOBAL_ENV.maxStackDepth.Value.(Int); ok && len(rt.callstack.frames) >= depth.I {
+		panic(rt.newStackOverflow(depth.I))
+	}
 	// TODO: this is all wrong. We cannot rely on
 	// currentExpr for stacktraces. Instead, each Callable
 	// should know it's name / position.
@@ -118,6 +222,21 @@ func (s *Callstack) popFrame() {
 	s.frames = s.frames[:len(s.frames)-1]
 }
 
+// tailCall replaces the innermost frame with the frame
+// of a tail call made by expr.
+func (s *Callstack) tailCall(expr *CallExpr) {
+	f := &s.frames[len(s.frames)-1]
+	f.traceable = tailCallFrame{pos: f.traceable.Pos(), expr: expr}
+}
+
+func (f tailCallFrame) Name() string {
+	return f.expr.Name()
+}
+
+func (f tailCallFrame) Pos() Position {
+	return f.pos
+}
+
 func (s *Callstack) clone() *Callstack {
 	res := &Callstack{frames: make([]Frame, len(s.frames))}
 	copy(res.frames, s.frames)
@@ -171,6 +290,22 @@ func (err *EvalError) Message() Object {
 	return MakeString(err.msg)
 }
 
+func (rt *Runtime) newStackOverflow(depth int) *StackOverflow {
+	return &StackOverflow{rt.NewError(fmt.Sprintf("Stack overflow: call depth exceeds *max-stack-depth* (%d)", depth))}
+}
+
+func (err *StackOverflow) Equals(other interface{}) bool {
+	return err == other
+}
+
+func (err *StackOverflow) GetType() *Type {
+	return TYPE.StackOverflow
+}
+
+func (err *StackOverflow) WithInfo(info *ObjectInfo) Object {
+	return err
+}
+
 func (err *EvalError) Error() string {
 	pos := err.pos
 	if len(err.rt.callstack.frames) > 0 && !LINTER_MODE {
@@ -188,6 +323,7 @@ func (expr *VarRefExpr) Eval(env *LocalEnv) Object {
 }
 
 func (expr *SetMacroExpr) Eval(env *LocalEnv) Object {
+	RT.checkNamespaceOwner(expr.vr.ns)
 	expr.vr.isMacro = true
 	expr.vr.isUsed = false
 	if fn, ok := expr.vr.Value.(*Fn); ok {
@@ -254,8 +390,9 @@ func (expr *SetExpr) Eval(env *LocalEnv) Object {
 }
 
 func (expr *DefExpr) Eval(env *LocalEnv) Object {
+	RT.checkNamespaceOwner(expr.vr.ns)
 	if expr.value != nil {
-		expr.vr.Value = Eval(expr.value, env)
+		RT.setVarRoot(expr.vr, Eval(expr.value, env))
 	}
 	meta := EmptyArrayMap()
 	meta.Add(KEYWORDS.line, Int{I: expr.startLine})
@@ -289,9 +426,26 @@ func evalSeq(exprs []Expr, env *LocalEnv) []Object {
 }
 
 func (expr *CallExpr) Eval(env *LocalEnv) Object {
+	return expr.call(Eval(expr.callable, env), env)
+}
+
+// evalTail evaluates expr in tail position of a function body.
+// Calls of Fns are returned as TailCalls instead of being made.
+func (expr *CallExpr) evalTail(env *LocalEnv) Object {
 	callable := Eval(expr.callable, env)
+	if fn, ok := callable.(*Fn); ok {
+		return &TailCall{fn: fn, args: evalSeq(expr.args, env), expr: expr}
+	}
+	parentExpr := RT.currentExpr
+	RT.currentExpr = expr
+	defer (func() { RT.currentExpr = parentExpr })()
+	return expr.call(callable, env)
+}
+
+func (expr *CallExpr) call(callable Object, env *LocalEnv) Object {
 	switch callable := callable.(type) {
 	case Callable:
+		RT.checkLimits()
 		args := evalSeq(expr.args, env)
 		return callable.Call(args)
 	default:
@@ -366,6 +520,61 @@ func evalBody(body []Expr, env *LocalEnv) Object {
 	return res
 }
 
+// evalTail evaluates expr in tail position of a function body
+// (see TailCall).
+func evalTail(expr Expr, env *LocalEnv) Object {
+	switch expr := expr.(type) {
+	case *CallExpr:
+		return expr.evalTail(env)
+	case *IfExpr:
+		if ToBool(Eval(expr.cond, env)) {
+			return evalTail(expr.positive, env)
+		}
+		return evalTail(expr.negative, env)
+	case *DoExpr:
+		return evalTailBody(expr.body, env)
+	case *LetExpr:
+		env = env.addEmptyFrame(len(expr.names))
+		for _, bindingExpr := range expr.values {
+			env.addBinding(Eval(bindingExpr, env))
+		}
+		return evalTailBody(expr.body, env)
+	case *LoopExpr:
+		env = env.addEmptyFrame(len(expr.names))
+		for _, bindingExpr := range expr.values {
+			env.addBinding(Eval(bindingExpr, env))
+		}
+		return evalTailLoop(expr.body, env)
+	default:
+		return Eval(expr, env)
+	}
+}
+
+func evalTailBody(body []Expr, env *LocalEnv) Object {
+	if len(body) == 0 {
+		return NIL
+	}
+	last := len(body) - 1
+	for _, expr := range body[:last] {
+		Eval(expr, env)
+	}
+	return evalTail(body[last], env)
+}
+
+// evalTailLoop is evalLoop for a function body or a loop
+// in tail position of one.
+func evalTailLoop(body []Expr, env *LocalEnv) Object {
+	for {
+		res := evalTailBody(body, env)
+		if rb, ok := res.(RecurBindings); ok {
+			RT.checkLimits()
+			env = env.replaceFrame(rb)
+			continue
+		}
+		return res
+	}
+}
+
 func evalLoop(body []Expr, env *LocalEnv) Object {
 	var res Object = NIL
 loop:
@@ -376,6 +585,7 @@ loop:
 	default:
 		return res
 	case RecurBindings:
+		RT.checkLimits()
 		env = env.replaceFrame(res)
 		goto loop
 	}
@@ -439,6 +649,10 @@ func TryEval(expr Expr) (obj Object, err error) {
 			switch r.(type) {
 			case *EvalError:
 				err = r.(error)
+			case *StackOverflow:
+				err = r.(error)
+			case *LimitExceeded:
+				err = r.(error)
 			case *ExInfo:
 				err = r.(error)
 			default:
@@ -446,7 +660,7 @@ func TryEval(expr Expr) (obj Object, err error) {
 			}
 		}
 	}()
-	return Eval(expr, nil), nil
+	return evalTopLevel(expr), nil
 }
 
 func PanicOnErr(err error) {
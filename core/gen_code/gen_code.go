@@ -96,6 +96,14 @@ var CoreSourceFiles []FileInfo = []FileInfo{
 		Name:     "<joker.better-cond>",
 		Filename: "better_cond.joke",
 	},
+	{
+		Name:     "<joker.async>",
+		Filename: "async.joke",
+	},
+	{
+		Name:     "<joker.parallel>",
+		Filename: "parallel.joke",
+	},
 }
 
 func parseArgs(args []string) {
@@ -10,6 +10,7 @@ type (
 	}
 	Node interface {
 		assoc(shift uint, hash uint32, key Object, val Object, addedLeaf *Box) Node
+		assocT(edit *editToken, shift uint, hash uint32, key Object, val Object, addedLeaf *Box) Node
 		without(shift uint, hash uint32, key Object) Node
 		find(shift uint, hash uint32, key Object) *Pair
 		nodeSeq() Seq
@@ -22,15 +23,18 @@ type (
 		root  Node
 	}
 	BitmapIndexedNode struct {
+		edit   *editToken
 		bitmap int
 		array  []interface{}
 	}
 	HashCollisionNode struct {
+		edit  *editToken
 		hash  uint32
 		count int
 		array []interface{}
 	}
 	ArrayNode struct {
+		edit  *editToken
 		count int
 		array []Node
 	}
@@ -750,6 +754,7 @@ func (m *HashMap) Assoc(key, val Object) Associative {
 	}
 	newcount := m.count
 	if addedLeaf.val != nil {
+		chargeAlloc(allocEntry)
 		newcount = m.count + 1
 	}
 	res := &HashMap{
@@ -865,6 +870,9 @@ func (m *HashMap) kvreduce(c Callable, init Object) Object {
 	for iter.HasNext() {
 		kv := iter.Next()
 		res = c.Call([]Object{res, kv.Key, kv.Value})
+		if r, ok := unreduced(res); ok {
+			return r
+		}
 	}
 	return res
 }
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// libCacheWriter collects the packed code of a lib
+// while it's being loaded from source.
+type libCacheWriter struct {
+	packEnv *PackEnv
+	code    []byte
+	// Macros expanded while parsing the lib. The cached code
+	// is only valid as long as the files defining them don't change.
+	macros map[*Var]bool
+}
+
+// LIB_CACHE makes libs loaded from *classpath* be cached
+// in packed form in ~/.jokerd/cache (see --no-lib-cache command
+// line option). There is one cache file per lib file name; it holds
+// the Joker version and the hashes of the lib, of the files defining
+// macros the lib uses and of the lib files loaded by the time the lib
+// was parsed. The cached code is used instead of reading and parsing
+// the lib until one of these changes, in which case the cache file
+// is overwritten.
+//
+// Cached code is what the lib's forms expanded to, so macros
+// aren't expanded again when it's evaluated: anything they do
+// at expansion time besides returning code, such as printing
+// warnings or changing vars, only happens when the lib
+// is loaded from source.
+var LIB_CACHE bool = true
+
+// loadedLibFiles holds the absolute names of the lib files loaded
+// so far. Macros may call functions defined in any of them at
+// expansion time, so they all go into the files checked by
+// the cache entries written after them.
+var loadedLibFiles = make(map[string]bool)
+
+const libCacheExt = ".jkc"
+
+func libCacheDir() string {
+	return filepath.Join(HomeDir(), ".jokerd", "cache")
+}
+
+func libCachePath(filename string) string {
+	h := sha256.New()
+	abs, _ := filepath.Abs(filename)
+	for _, s := range []string{filename, abs} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return filepath.Join(libCacheDir(), hex.EncodeToString(h.Sum(nil))+libCacheExt)
+}
+
+func fileHash(filename string) ([]byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(content)
+	return h[:], nil
+}
+
+// loadLib evaluates the code of the lib in filename,
+// using the lib cache if it's enabled.
+func loadLib(f *os.File, filename string) {
+	if !LIB_CACHE {
+		ProcessReaderFromEval(NewReader(bufio.NewReader(f), filename), filename)
+		return
+	}
+	// The lib's own file goes into the files checked by its cache entry too.
+	if abs, err := filepath.Abs(filename); err == nil {
+		loadedLibFiles[abs] = true
+	}
+	content, err := io.ReadAll(f)
+	PanicOnErr(err)
+	cachePath := libCachePath(filename)
+	if code := readLibCache(cachePath); code != nil {
+		if VerbosityLevel > 0 {
+			fmt.Fprintf(Stderr, "loadLib: Using cached code for %s\n", filename)
+		}
+		evalPackedLib(code, filename)
+		return
+	}
+	w := &libCacheWriter{
+		packEnv: NewPackEnv(),
+		macros:  make(map[*Var]bool),
+	}
+	processReaderFromEval(NewReader(bytes.NewReader(content), filename), filename, w)
+	if err := w.write(cachePath); err != nil && VerbosityLevel > 0 {
+		fmt.Fprintf(Stderr, "loadLib: Not caching code for %s: %s\n", filename, err)
+	}
+}
+
+func evalPackedLib(code []byte, filename string) {
+	currentFilename := GLOBAL_ENV.file.Value
+	defer func() {
+		GLOBAL_ENV.SetFilename(currentFilename)
+	}()
+	s, err := filepath.Abs(filename)
+	PanicOnErr(err)
+	GLOBAL_ENV.SetFilename(MakeString(s))
+	header, p := UnpackHeader(code, GLOBAL_ENV)
+	for len(p) > 0 {
+		var expr Expr
+		expr, p = UnpackExpr(p, header)
+		_, err := TryEval(expr)
+		PanicOnErr(err)
+	}
+}
+
+func (w *libCacheWriter) add(expr Expr) {
+	w.code = expr.Pack(w.code, w.packEnv)
+}
+
+// Layout of a lib cache file:
+//
+//	sha256 of the rest of the file
+//	length of the Joker version, then the version
+//	number of dependency files (the lib, macro and lib files), then the name and sha256 of each
+//	packed code (see PackEnv.Pack and Expr.Pack)
+func (w *libCacheWriter) write(cachePath string) error {
+	if w.packEnv.unpackable {
+		return fmt.Errorf("code has objects that can't be packed")
+	}
+	if len(w.packEnv.Strings) > 0xffff {
+		return fmt.Errorf("too many strings")
+	}
+	files := make(map[string]bool)
+	for name := range loadedLibFiles {
+		files[name] = true
+	}
+	for vr := range w.macros {
+		var file Object = NIL
+		if vr.meta != nil {
+			_, file = vr.meta.Get(KEYWORDS.file)
+		}
+		s, ok := file.(String)
+		if !ok {
+			return fmt.Errorf("no file for macro %s", vr.ToString(false))
+		}
+		if strings.HasPrefix(s.S, "<") {
+			continue
+		}
+		name, err := filepath.Abs(s.S)
+		if err != nil {
+			return err
+		}
+		files[name] = true
+	}
+	var p []byte
+	p = appendInt(p, len(VERSION))
+	p = append(p, VERSION...)
+	p = appendInt(p, len(files))
+	for name := range files {
+		hash, err := fileHash(name)
+		if err != nil {
+			return err
+		}
+		p = appendInt(p, len(name))
+		p = append(p, name...)
+		p = append(p, hash...)
+	}
+	p = w.packEnv.Pack(p)
+	p = append(p, w.code...)
+	sum := sha256.Sum256(p)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0777); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(append(sum[:], p...))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath)
+}
+
+// readLibCache returns the packed code from the lib cache file
+// at cachePath, or nil if there is no valid one.
+func readLibCache(cachePath string) []byte {
+	data, err := os.ReadFile(cachePath)
+	if err != nil || len(data) < sha256.Size {
+		return nil
+	}
+	sum, p := data[:sha256.Size], data[sha256.Size:]
+	if actual := sha256.Sum256(p); !bytes.Equal(sum, actual[:]) {
+		return nil
+	}
+	// A file with a valid checksum may still be inconsistent
+	// (e.g. written by hand), so lengths are checked, not trusted.
+	if len(p) < 8 {
+		return nil
+	}
+	length, p := extractInt(p)
+	if length < 0 || length > len(p) || string(p[:length]) != VERSION {
+		return nil
+	}
+	p = p[length:]
+	if len(p) < 8 {
+		return nil
+	}
+	count, p := extractInt(p)
+	for i := 0; i < count; i++ {
+		if len(p) < 8 {
+			return nil
+		}
+		var length int
+		length, p = extractInt(p)
+		if length < 0 || length > len(p)-sha256.Size {
+			return nil
+		}
+		name := string(p[:length])
+		hash := p[length : length+sha256.Size]
+		p = p[length+sha256.Size:]
+		if actual, err := fileHash(name); err != nil || !bytes.Equal(hash, actual) {
+			return nil
+		}
+	}
+	return p
+}
@@ -0,0 +1,290 @@
+package core
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// evalLimits limits the time, the number of steps
+	// (function calls and loop iterations) and the memory
+	// allocated for collections and strings evaluation can take.
+	// Limits are nested: the limits of an inner scope
+	// never exceed what's left of the limits of the outer one.
+	evalLimits struct {
+		// Time limit as given, and when it expires.
+		timeout  time.Duration
+		deadline time.Time
+		// Closed and set when the deadline passes.
+		// Shared with the outer scope if its deadline comes first.
+		done    chan struct{}
+		expired *atomic.Bool
+		// Timer closing done, if it was started by this scope.
+		timer *time.Timer
+		// Step budget as given, the effective budget
+		// and the number of steps taken so far.
+		// Only changed by the goroutine holding the GIL.
+		budget   int
+		maxSteps int
+		steps    int
+		// Same for the allocation budget, in bytes (see chargeAlloc).
+		allocBudget int
+		maxAlloc    int
+		allocated   int
+	}
+	// LimitExceeded is raised when evaluation takes longer, more
+	// steps or more memory than allowed by with-timeout,
+	// with-step-limit, with-alloc-limit or --eval-timeout.
+	LimitExceeded struct {
+		*EvalError
+	}
+)
+
+// EVAL_TIMEOUT is the time limit for evaluating a script or an
+// expression given on the command line, or each form entered in the REPL
+// (see --eval-timeout command line option). Zero means no limit.
+var EVAL_TIMEOUT time.Duration
+
+// Estimated sizes, in bytes, of what's charged against
+// the allocation budget (see chargeAlloc).
+const (
+	// An element of a vector, list or lazy seq.
+	allocElem = 16
+	// An entry of a map or an element of a set.
+	allocEntry = 32
+)
+
+// remaining returns what's left of a budget of max of which used
+// has been spent, for a nested scope. It's at least 1, as 0 means
+// no limit.
+func remaining(max, used int) int {
+	if used >= max {
+		return 1
+	}
+	return max - used
+}
+
+// newEvalLimits returns limits for a scope nested in parent.
+// Zero timeout, maxSteps or maxAlloc means no limit of that kind
+// other than inherited from parent.
+func newEvalLimits(parent *evalLimits, timeout time.Duration, maxSteps int, maxAlloc int) *evalLimits {
+	l := &evalLimits{}
+	if parent != nil {
+		l.timeout, l.deadline, l.done, l.expired = parent.timeout, parent.deadline, parent.done, parent.expired
+		if parent.maxSteps > 0 {
+			l.budget, l.maxSteps = parent.budget, remaining(parent.maxSteps, parent.steps)
+		}
+		if parent.maxAlloc > 0 {
+			l.allocBudget, l.maxAlloc = parent.allocBudget, remaining(parent.maxAlloc, parent.allocated)
+		}
+	}
+	if maxSteps > 0 && (l.maxSteps == 0 || maxSteps < l.maxSteps) {
+		l.budget, l.maxSteps = maxSteps, maxSteps
+	}
+	if maxAlloc > 0 && (l.maxAlloc == 0 || maxAlloc < l.maxAlloc) {
+		l.allocBudget, l.maxAlloc = maxAlloc, maxAlloc
+	}
+	if timeout > 0 {
+		deadline := time.Now().Add(timeout)
+		if l.done == nil || deadline.Before(l.deadline) {
+			done, expired := make(chan struct{}), &atomic.Bool{}
+			l.timeout, l.deadline, l.done, l.expired = timeout, deadline, done, expired
+			l.timer = time.AfterFunc(timeout, func() {
+				expired.Store(true)
+				close(done)
+			})
+		}
+	}
+	return l
+}
+
+// stop releases the timer of l, if any. Goroutines spawned under l
+// that outlive it are no longer interrupted when the time is up.
+func (l *evalLimits) stop() {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// checkLimits panics with LimitExceeded if rt has run out of
+// time or steps. It's called on every function call
+// and loop iteration.
+func (rt *Runtime) checkLimits() {
+	l := rt.limits
+	if l == nil {
+		return
+	}
+	rt.checkTimeout()
+	if l.maxSteps > 0 {
+		if l.steps >= l.maxSteps {
+			panic(rt.NewLimitExceeded(fmt.Sprintf("Step limit exceeded: evaluation took more than %d steps", l.budget)))
+		}
+		l.steps++
+	}
+}
+
+// chargeAlloc counts n bytes allocated by the goroutine holding the GIL
+// against its allocation budget, panicking with LimitExceeded if
+// the budget is exceeded. It's called where vectors, lists, lazy seqs,
+// maps, sets and strings are built, with estimated sizes (see allocElem);
+// other allocations aren't counted.
+func chargeAlloc(n int) {
+	l := RT.limits
+	if l == nil || l.maxAlloc == 0 {
+		return
+	}
+	l.allocated += n
+	if l.allocated > l.maxAlloc {
+		panic(RT.NewLimitExceeded(fmt.Sprintf("Allocation limit exceeded: evaluation allocated more than %d bytes", l.allocBudget)))
+	}
+}
+
+// checkTimeout panics with LimitExceeded if rt has run out of time.
+func (rt *Runtime) checkTimeout() {
+	if l := rt.limits; l != nil && l.expired != nil && l.expired.Load() {
+		panic(rt.newTimeout(l.timeout))
+	}
+}
+
+// RunBlocking calls f, which blocks (e.g. on I/O), with the GIL released
+// so that other goroutines can run. If the time limit of rt expires
+// before f returns, cancel (unless nil) is called to make f return early.
+// Either way, RunBlocking panics with LimitExceeded if rt has run
+// out of time once it reacquires the GIL.
+func (rt *Runtime) RunBlocking(f func(), cancel func()) {
+	rt.ReleaseGIL()
+	stop := make(chan struct{})
+	if done := rt.limitsDone(); done != nil && cancel != nil {
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-stop:
+			}
+		}()
+	}
+	func() {
+		defer func() {
+			close(stop)
+			rt.AcquireGIL()
+		}()
+		f()
+	}()
+	rt.checkTimeout()
+}
+
+// Sleep pauses the goroutine of rt for d like RunBlocking,
+// waking up early if the time limit of rt expires.
+func (rt *Runtime) Sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	wake := make(chan struct{})
+	rt.RunBlocking(func() {
+		select {
+		case <-timer.C:
+		case <-wake:
+		}
+	}, func() { close(wake) })
+}
+
+// limitsDone returns a channel that's closed when the time limit
+// of rt expires. Joker code blocked on channels or futures
+// waits on it too so that it can be interrupted.
+// Returns nil (which blocks forever) if rt has no time limit.
+func (rt *Runtime) limitsDone() <-chan struct{} {
+	if rt.limits == nil {
+		return nil
+	}
+	return rt.limits.done
+}
+
+func (rt *Runtime) newTimeout(timeout time.Duration) *LimitExceeded {
+	return rt.NewLimitExceeded(fmt.Sprintf("Timeout: evaluation took longer than %d ms", timeout.Milliseconds()))
+}
+
+// WithLimits calls f with limits nested in the current ones.
+// Zero timeout, maxSteps or maxAlloc (in bytes) means no limit
+// of that kind.
+func (rt *Runtime) WithLimits(timeout time.Duration, maxSteps int, maxAlloc int, f func() Object) Object {
+	l := newEvalLimits(rt.limits, timeout, maxSteps, maxAlloc)
+	parent := rt.limits
+	rt.limits = l
+	defer func() {
+		// f may have spawned goroutines that still run under l.
+		l.stop()
+		rt.limits = parent
+		if parent != nil {
+			parent.steps += l.steps
+			parent.allocated += l.allocated
+		}
+	}()
+	return f()
+}
+
+// limitEvalTime makes evaluation by rt time out after EVAL_TIMEOUT
+// unless it's already limited. Returns the function that lifts the limit.
+func (rt *Runtime) limitEvalTime() func() {
+	if EVAL_TIMEOUT <= 0 || rt.limits != nil {
+		return func() {}
+	}
+	l := newEvalLimits(nil, EVAL_TIMEOUT, 0, 0)
+	rt.limits = l
+	return func() {
+		l.stop()
+		rt.limits = nil
+	}
+}
+
+// EvalWithTimeout evaluates expr like Eval, but with the time limit
+// EVAL_TIMEOUT (see --eval-timeout command line option).
+func EvalWithTimeout(expr Expr, env *LocalEnv) Object {
+	defer RT.limitEvalTime()()
+	return Eval(expr, env)
+}
+
+func (rt *Runtime) NewLimitExceeded(msg string) *LimitExceeded {
+	return &LimitExceeded{rt.NewError(msg)}
+}
+
+func (err *LimitExceeded) Equals(other interface{}) bool {
+	return err == other
+}
+
+func (err *LimitExceeded) GetType() *Type {
+	return TYPE.LimitExceeded
+}
+
+func (err *LimitExceeded) WithInfo(info *ObjectInfo) Object {
+	return err
+}
+
+var procWithEvalLimits = func(args []Object) Object {
+	CheckArity(args, 4, 4)
+	var timeout time.Duration
+	if !args[0].Equals(NIL) {
+		ms := EnsureArgIsInt(args, 0).I
+		if ms <= 0 {
+			panic(RT.NewArgTypeError(0, args[0], "positive Int"))
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	var maxSteps int
+	if !args[1].Equals(NIL) {
+		maxSteps = EnsureArgIsInt(args, 1).I
+		if maxSteps <= 0 {
+			panic(RT.NewArgTypeError(1, args[1], "positive Int"))
+		}
+	}
+	var maxAlloc int
+	if !args[2].Equals(NIL) {
+		maxAlloc = EnsureArgIsInt(args, 2).I
+		if maxAlloc <= 0 {
+			panic(RT.NewArgTypeError(2, args[2], "positive Int"))
+		}
+	}
+	f := EnsureArgIsCallable(args, 3)
+	return RT.WithLimits(timeout, maxSteps, maxAlloc, func() Object {
+		return f.Call([]Object{})
+	})
+}
@@ -0,0 +1,371 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type (
+	lintPosition struct {
+		File        string `json:"file"`
+		StartLine   int    `json:"startLine"`
+		StartColumn int    `json:"startColumn"`
+		EndLine     int    `json:"endLine"`
+		EndColumn   int    `json:"endColumn"`
+	}
+
+	lintProblemData struct {
+		Pos      lintPosition `json:"pos"`
+		Phase    string       `json:"phase"`
+		Rule     string       `json:"rule"`
+		Severity LintSeverity `json:"severity"`
+		Message  string       `json:"message"`
+	}
+
+	lintSuppressionData struct {
+		Pos lintPosition `json:"pos"`
+		// Range of the form the suppression applies to.
+		Range lintPosition `json:"range"`
+		Rules []string     `json:"rules"`
+	}
+
+	lintReferenceData struct {
+		Pos        lintPosition `json:"pos"`
+		Ns         string       `json:"ns"`
+		Name       string       `json:"name"`
+		Args       int          `json:"args"`
+		Unresolved bool         `json:"unresolved,omitempty"`
+	}
+
+	// lintUsage tells which namespaces and public vars defined by
+	// linted files are globally used (see --report-globally-unused).
+	lintUsage struct {
+		// Namespaces and vars not used by the files that
+		// define them, with their positions.
+		Namespaces map[string]lintPosition `json:"namespaces"`
+		Vars       map[string]lintPosition `json:"vars"`
+		// Namespaces and vars used by the linted files.
+		UsedNamespaces []string `json:"usedNamespaces"`
+		UsedVars       []string `json:"usedVars"`
+	}
+
+	// LintEnv is the state of the environment before linting the files
+	// of a directory, which is restored after linting each one, so that
+	// none of them sees the namespaces and vars of the files linted
+	// before it. References to other files are checked against the
+	// project's index instead (see CheckLintReferences).
+	LintEnv struct {
+		namespaces map[*string]*Namespace
+		// Saved state of namespaces that were loaded, and of their vars.
+		// Namespaces loaded lazily later on are the same for every file.
+		nsStates  map[*Namespace]Namespace
+		varStates map[*Var]Var
+	}
+
+	// LintResult is what linting a share of the files of a directory
+	// found. When a directory is linted by several workers (see --lint-jobs),
+	// each one lints its files in its own process and environment, and
+	// the results are merged by MergeLintResult. Problems are reported
+	// by the merging process, which also runs the checks that need
+	// to know about all the files. Since each file is linted in the
+	// environment it would be linted in on its own (see LintEnv), the
+	// results don't depend on how the files are split between workers.
+	LintResult struct {
+		// Joker version and result format of the worker,
+		// which must be the same as those of the merging process.
+		Version      string                    `json:"version"`
+		Format       int                       `json:"format"`
+		Problems     []lintProblemData         `json:"problems"`
+		Suppressions []lintSuppressionData     `json:"suppressions"`
+		References   []lintReferenceData       `json:"references"`
+		Index        map[string]*lintIndexFile `json:"index"`
+		Usage        *lintUsage                `json:"usage"`
+		ProblemCount int                       `json:"problemCount"`
+		// Files that failed to be processed.
+		Failed []string `json:"failed"`
+	}
+)
+
+// lintResultFormat is the version of the format of LintResult.
+// Change it when changing LintResult.
+const lintResultFormat = 2
+
+var (
+	// LINT_COLLECT makes problems be collected as they are found,
+	// before rule severities and suppressions are applied,
+	// to be returned by TakeLintResult.
+	LINT_COLLECT bool
+	// LINT_SORT makes problems in "text" format be collected
+	// like the others, to be printed by SortLintProblems.
+	LINT_SORT bool
+	// LINT_WORKER is set in processes linting files for
+	// another one, which reports config errors.
+	LINT_WORKER bool
+
+	lintCollected   []LintProblem
+	lintFileUsage   = newLintUsage()
+	lintGlobalUsage = newLintUsage()
+)
+
+func makeLintPosition(pos Position) lintPosition {
+	return lintPosition{
+		File:        pos.Filename(),
+		StartLine:   pos.startLine,
+		StartColumn: pos.startColumn,
+		EndLine:     pos.endLine,
+		EndColumn:   pos.endColumn,
+	}
+}
+
+func (p lintPosition) position() Position {
+	return Position{
+		filename:    STRINGS.Intern(p.File),
+		startLine:   p.StartLine,
+		startColumn: p.StartColumn,
+		endLine:     p.EndLine,
+		endColumn:   p.EndColumn,
+	}
+}
+
+func newLintUsage() *lintUsage {
+	return &lintUsage{
+		Namespaces: make(map[string]lintPosition),
+		Vars:       make(map[string]lintPosition),
+	}
+}
+
+// merge adds other, found after u, to u. As when the files are linted
+// one by one, positions found last win.
+func (u *lintUsage) merge(other *lintUsage) {
+	for name, pos := range other.Namespaces {
+		u.Namespaces[name] = pos
+	}
+	for name, pos := range other.Vars {
+		u.Vars[name] = pos
+	}
+	u.UsedNamespaces = append(u.UsedNamespaces, other.UsedNamespaces...)
+	u.UsedVars = append(u.UsedVars, other.UsedVars...)
+}
+
+// unused returns the entries of candidates whose names aren't in used.
+func (u *lintUsage) unused(candidates map[string]lintPosition, used []string) map[string]lintPosition {
+	res := make(map[string]lintPosition, len(candidates))
+	for name, pos := range candidates {
+		res[name] = pos
+	}
+	for _, name := range used {
+		delete(res, name)
+	}
+	return res
+}
+
+func sortedKeys(m map[string]lintPosition) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func copyNamespace(ns *Namespace) Namespace {
+	res := *ns
+	res.mappings = make(map[*string]*Var, len(ns.mappings))
+	for name, vr := range ns.mappings {
+		res.mappings[name] = vr
+	}
+	res.aliases = make(map[*string]*Namespace, len(ns.aliases))
+	for name, alias := range ns.aliases {
+		res.aliases[name] = alias
+	}
+	return res
+}
+
+// SaveLintEnv returns the current state of the environment.
+func SaveLintEnv() *LintEnv {
+	res := &LintEnv{
+		namespaces: make(map[*string]*Namespace, len(GLOBAL_ENV.Namespaces)),
+		nsStates:   make(map[*Namespace]Namespace),
+		varStates:  make(map[*Var]Var),
+	}
+	for name, ns := range GLOBAL_ENV.Namespaces {
+		res.namespaces[name] = ns
+		if ns.Lazy != nil {
+			continue
+		}
+		res.nsStates[ns] = copyNamespace(ns)
+		for _, vr := range ns.mappings {
+			if vr.ns == ns {
+				res.varStates[vr] = *vr
+			}
+		}
+	}
+	return res
+}
+
+// Restore brings the environment back to the state saved in e.
+func (e *LintEnv) Restore() {
+	for name := range GLOBAL_ENV.Namespaces {
+		if e.namespaces[name] == nil {
+			delete(GLOBAL_ENV.Namespaces, name)
+		}
+	}
+	for name, ns := range e.namespaces {
+		GLOBAL_ENV.Namespaces[name] = ns
+	}
+	for ns, state := range e.nsStates {
+		*ns = copyNamespace(&state)
+	}
+	for vr, state := range e.varStates {
+		*vr = state
+	}
+	lintUnresolvedNamespaces = nil
+}
+
+// CollectLintFileUsage adds the usage of the namespaces and vars
+// by the file just linted to the usage returned by TakeLintResult.
+// It must be called before the environment is restored.
+func CollectLintFileUsage() {
+	lintFileUsage.merge(collectLintUsage())
+}
+
+// TakeLintResult returns what was found since the last call,
+// which requires LINT_COLLECT to be set while linting, and
+// resets the linter's state, except for the environment.
+func TakeLintResult(failed []string) *LintResult {
+	res := &LintResult{
+		Version:      VERSION,
+		Format:       lintResultFormat,
+		Usage:        lintFileUsage,
+		ProblemCount: PROBLEM_COUNT,
+		Failed:       failed,
+	}
+	lintFileUsage = newLintUsage()
+	for _, p := range lintCollected {
+		res.Problems = append(res.Problems, lintProblemData{
+			Pos:      makeLintPosition(p.pos),
+			Phase:    p.phase,
+			Rule:     p.rule,
+			Severity: p.severity,
+			Message:  p.msg,
+		})
+	}
+	for _, s := range lintSuppressions {
+		res.Suppressions = append(res.Suppressions, lintSuppressionData{
+			Pos: makeLintPosition(s.pos),
+			Range: lintPosition{
+				File:        s.filename,
+				StartLine:   s.startLine,
+				StartColumn: s.startColumn,
+				EndLine:     s.endLine,
+				EndColumn:   s.endColumn,
+			},
+			Rules: s.rules,
+		})
+	}
+	for _, ref := range lintReferences {
+		res.References = append(res.References, lintReferenceData{
+			Pos:        makeLintPosition(ref.pos),
+			Ns:         ref.ns,
+			Name:       ref.name,
+			Args:       ref.args,
+			Unresolved: ref.unresolved,
+		})
+	}
+	if LINT_INDEX != nil {
+		res.Index = LINT_INDEX.Files
+		LINT_INDEX = newLintIndex(LINT_INDEX.path)
+	}
+	lintCollected = nil
+	lintSuppressions = nil
+	lintReferences = nil
+	PROBLEM_COUNT = 0
+	return res
+}
+
+// MergeLintResult reports the problems found by a worker
+// and adds the rest of r to what's known about the directory.
+func MergeLintResult(r *LintResult) {
+	for _, s := range r.Suppressions {
+		lintSuppressions = append(lintSuppressions, &lintSuppression{
+			pos:         s.Pos.position(),
+			filename:    s.Range.File,
+			startLine:   s.Range.StartLine,
+			startColumn: s.Range.StartColumn,
+			endLine:     s.Range.EndLine,
+			endColumn:   s.Range.EndColumn,
+			rules:       s.Rules,
+			used:        make(map[string]bool),
+		})
+	}
+	for _, p := range r.Problems {
+		reportLintProblem(LintProblem{
+			pos:      p.Pos.position(),
+			phase:    p.Phase,
+			rule:     p.Rule,
+			severity: p.Severity,
+			msg:      p.Message,
+		})
+	}
+	for _, ref := range r.References {
+		lintReferences = append(lintReferences, lintReference{
+			pos:        ref.Pos.position(),
+			ns:         ref.Ns,
+			name:       ref.Name,
+			args:       ref.Args,
+			unresolved: ref.Unresolved,
+		})
+	}
+	if LINT_INDEX != nil {
+		for name, file := range r.Index {
+			LINT_INDEX.Files[name] = file
+			LINT_INDEX.merge(file)
+		}
+	}
+	if r.Usage != nil {
+		lintGlobalUsage.merge(r.Usage)
+	}
+	PROBLEM_COUNT += r.ProblemCount
+}
+
+// WriteLintResult writes r to w, for ReadLintResult.
+func WriteLintResult(w io.Writer, r *LintResult) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// ReadLintResult reads a result written by WriteLintResult, which
+// must come from the same version of Joker (e.g. the executable of the
+// process may have been replaced by another build since it started).
+func ReadLintResult(data []byte) (*LintResult, error) {
+	res := &LintResult{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("invalid lint result: %s", err)
+	}
+	if res.Version != VERSION || res.Format != lintResultFormat {
+		return nil, fmt.Errorf("lint result of Joker %s (format %d), expected %s (format %d)", res.Version, res.Format, VERSION, lintResultFormat)
+	}
+	return res, nil
+}
+
+// SortLintProblems sorts the problems collected with LINT_SORT
+// by file and position, and prints them if LINT_FORMAT is "text".
+func SortLintProblems() {
+	sort.SliceStable(lintProblems, func(i, j int) bool {
+		a, b := lintProblems[i].pos, lintProblems[j].pos
+		switch {
+		case a.Filename() != b.Filename():
+			return a.Filename() < b.Filename()
+		case a.startLine != b.startLine:
+			return a.startLine < b.startLine
+		}
+		return a.startColumn < b.startColumn
+	})
+	if LINT_FORMAT == "text" {
+		for _, p := range lintProblems {
+			fmt.Fprintln(Stderr, p)
+		}
+		lintProblems = nil
+	}
+}
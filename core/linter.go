@@ -0,0 +1,537 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// LintSeverity is the severity of problems found by a linter rule.
+	LintSeverity int
+
+	// LintProblem is a warning or error found by the linter.
+	LintProblem struct {
+		pos Position
+		// "Read", "Parse", "Eval" or "Exception".
+		phase    string
+		rule     string
+		severity LintSeverity
+		msg      string
+	}
+
+	// lintSuppression turns off rules for the problems found
+	// in a form, e.g. #_{:joker/ignore [:unused-binding]} (let [a 1] 2).
+	lintSuppression struct {
+		// Position of the {:joker/ignore ...} map.
+		pos Position
+		// Range of the form the suppression applies to.
+		// endLine is 0 until the form is read.
+		filename                                   string
+		startLine, startColumn, endLine, endColumn int
+		// nil means all rules.
+		rules []string
+		used  map[string]bool
+	}
+
+	lintProblemJSON struct {
+		File        string `json:"file"`
+		Rule        string `json:"rule"`
+		Severity    string `json:"severity"`
+		Message     string `json:"message"`
+		StartLine   int    `json:"startLine"`
+		StartColumn int    `json:"startColumn"`
+		EndLine     int    `json:"endLine"`
+		EndColumn   int    `json:"endColumn"`
+	}
+)
+
+const (
+	LINT_OFF LintSeverity = iota
+	LINT_INFO
+	LINT_WARNING
+	LINT_ERROR
+)
+
+var (
+	// LINT_FORMAT is the format linter problems are reported in
+	// (see --lint-format): "text", "json" or "sarif". Text problems
+	// are printed to Stderr as they are found; the others are collected
+	// and printed by PrintLintProblems once linting is done.
+	LINT_FORMAT  = "text"
+	lintProblems []LintProblem
+	// Highest severity of the problems reported so far.
+	lintMaxSeverity = LINT_OFF
+
+	// lintRules maps ids of the linter's rules to their default
+	// severities, which can be changed in the :rules map of .joker.
+	// Problems that stop processing of the file (reported with ids
+	// read-error, parse-error, eval-error and exception) are always errors.
+	lintRules = map[string]LintSeverity{
+		// Reader.
+		"invalid-ident":        LINT_WARNING,
+		"reader-conditional":   LINT_ERROR,
+		"unknown-tag":          LINT_WARNING,
+		"unresolved-namespace": LINT_WARNING,
+		// Parser.
+		"arg-type":                  LINT_WARNING,
+		"duplicate-alias":           LINT_ERROR,
+		"duplicate-def":             LINT_WARNING,
+		"empty-bindings":            LINT_WARNING,
+		"empty-body":                LINT_WARNING,
+		"fn-with-empty-body":        LINT_WARNING,
+		"globally-unused-namespace": LINT_WARNING,
+		"globally-unused-var":       LINT_WARNING,
+		"if-without-else":           LINT_OFF,
+		"inline-def":                LINT_WARNING,
+		"not-a-function":            LINT_WARNING,
+		"private-var":               LINT_WARNING,
+		"qualified-binding":         LINT_ERROR,
+		"redundant-do":              LINT_WARNING,
+		"replaced-var":              LINT_WARNING,
+		"try-without-catch":         LINT_WARNING,
+		"unresolved-symbol":         LINT_ERROR,
+		"unresolved-var":            LINT_WARNING,
+		"unused-binding":            LINT_WARNING,
+		"unused-fn-parameters":      LINT_OFF,
+		"unused-namespace":          LINT_WARNING,
+		"unused-var":                LINT_WARNING,
+		"var-is-type":               LINT_WARNING,
+		"wrong-arity":               LINT_WARNING,
+		// Macros.
+		"condp-default-only":  LINT_WARNING,
+		"condp-no-clauses":    LINT_ERROR,
+		"duplicate-case":      LINT_ERROR,
+		"duplicate-require":   LINT_WARNING,
+		"empty-cond":          LINT_WARNING,
+		"empty-destructuring": LINT_WARNING,
+		"no-forms-threading":  LINT_WARNING,
+		"odd-clauses":         LINT_WARNING,
+		"unknown-namespace":   LINT_WARNING,
+		// Whether :as and :keys (:strs, :syms) bindings
+		// are checked by unused-binding.
+		"unused-as":   LINT_WARNING,
+		"unused-keys": LINT_WARNING,
+		// Suppressions.
+		"invalid-suppression": LINT_ERROR,
+		"unused-suppression":  LINT_WARNING,
+	}
+	// Severities set in .joker.
+	lintSeverities = map[string]LintSeverity{}
+
+	lintSuppressions []*lintSuppression
+)
+
+var lintSeverityNames = []string{"off", "info", "warning", "error"}
+
+func (s LintSeverity) String() string {
+	return lintSeverityNames[s]
+}
+
+// SetLintSeverity sets the severity of problems found by rule.
+// v is :off, :info, :warning or :error; true and false turn the rule
+// on (with its default severity, or as a warning if it's off by default)
+// and off.
+func SetLintSeverity(rule string, v Object) error {
+	def, ok := lintRules[rule]
+	if !ok {
+		return fmt.Errorf("unknown rule :%s", rule)
+	}
+	switch v := v.(type) {
+	case Boolean:
+		switch {
+		case !v.B:
+			lintSeverities[rule] = LINT_OFF
+		case def == LINT_OFF:
+			lintSeverities[rule] = LINT_WARNING
+		default:
+			lintSeverities[rule] = def
+		}
+		return nil
+	case Keyword:
+		for s, name := range lintSeverityNames {
+			if v.ns == nil && *v.name == name {
+				lintSeverities[rule] = LintSeverity(s)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf(":%s value (in :rules) must be :off, :info, :warning, :error, true or false; got %s", rule, v.ToString(true))
+}
+
+// lintSeverity returns the severity of problems found by rule.
+func lintSeverity(rule string) LintSeverity {
+	if s, ok := lintSeverities[rule]; ok {
+		return s
+	}
+	return lintRules[rule]
+}
+
+func resetLintSeverities() {
+	lintSeverities = map[string]LintSeverity{}
+}
+
+// LintExitCode returns the exit code of the linter: 0 if it found
+// no problems (or only info ones), 1 if the most severe problems
+// it found are warnings and 2 if there are errors.
+func LintExitCode() int {
+	switch {
+	case lintMaxSeverity == LINT_ERROR:
+		return 2
+	case lintMaxSeverity == LINT_WARNING, PROBLEM_COUNT > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsValidLintFormat returns true if format is a valid LINT_FORMAT.
+func IsValidLintFormat(format string) bool {
+	switch format {
+	case "text", "json", "sarif":
+		return true
+	}
+	return false
+}
+
+// Rule returns the id of the linter rule p violates.
+func (p LintProblem) Rule() string {
+	return p.rule
+}
+
+// Severity returns "info", "warning" or "error".
+func (p LintProblem) Severity() string {
+	return p.severity.String()
+}
+
+// kind returns the issue type of p printed in text format,
+// e.g. "Parse warning".
+func (p LintProblem) kind() string {
+	if p.phase == "Exception" {
+		return p.phase
+	}
+	return p.phase + " " + p.Severity()
+}
+
+func (p LintProblem) endPosition() (int, int) {
+	if p.pos.endLine < p.pos.startLine {
+		return p.pos.startLine, p.pos.startColumn
+	}
+	return p.pos.endLine, p.pos.endColumn
+}
+
+func (p LintProblem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.pos.Filename(), p.pos.startLine, p.pos.startColumn, p.kind(), p.msg)
+}
+
+// reportLintProblem reports p with the severity of its rule
+// set in .joker, if any. Problems of rules turned off are dropped.
+// With LINT_COLLECT, p is only collected, as found.
+func reportLintProblem(p LintProblem) {
+	if LINT_COLLECT {
+		lintCollected = append(lintCollected, p)
+		return
+	}
+	if s, ok := lintSeverities[p.rule]; ok {
+		p.severity = s
+	}
+	if p.severity == LINT_OFF || isSuppressed(p) {
+		return
+	}
+	if p.severity >= LINT_WARNING {
+		PROBLEM_COUNT++
+	}
+	if p.severity > lintMaxSeverity {
+		lintMaxSeverity = p.severity
+	}
+	if LINT_FORMAT != "text" {
+		p.msg = strings.TrimSpace(p.msg)
+	} else if !LINT_SORT {
+		fmt.Fprintln(Stderr, p)
+		return
+	}
+	lintProblems = append(lintProblems, p)
+}
+
+// addLintSuppression starts the suppression of the rules listed
+// under :joker/ignore in meta (a map read by reader) for the form
+// that follows. It's a keyword or a vector of keywords naming rules,
+// or true, meaning all rules.
+func addLintSuppression(reader *Reader, meta Object) {
+	m, ok := meta.(Map)
+	if !LINTER_MODE || !ok {
+		return
+	}
+	ok, v := m.Get(MakeKeyword("joker/ignore"))
+	if !ok {
+		return
+	}
+	s := &lintSuppression{
+		filename:    filename(reader.filename),
+		startLine:   reader.line,
+		startColumn: reader.column,
+		used:        make(map[string]bool),
+	}
+	if info := meta.GetInfo(); info != nil {
+		s.pos = info.Position
+	}
+	switch v := v.(type) {
+	case Keyword:
+		s.rules = []string{v.Name()}
+	case Seqable:
+		for seq := v.Seq(); !seq.IsEmpty(); seq = seq.Rest() {
+			k, ok := seq.First().(Keyword)
+			if !ok {
+				printReadError(reader, "invalid-suppression", ":joker/ignore elements must be keywords, got "+seq.First().GetType().ToString(false))
+				return
+			}
+			s.rules = append(s.rules, k.Name())
+		}
+	default:
+		if !ToBool(v) {
+			return
+		}
+	}
+	lintSuppressions = append(lintSuppressions, s)
+}
+
+// closeLintSuppressions ends the suppressions started
+// since there were n of them at the current position of reader,
+// right after the form they apply to.
+func closeLintSuppressions(reader *Reader, n int) {
+	for _, s := range lintSuppressions[n:] {
+		if s.endLine == 0 {
+			s.endLine, s.endColumn = reader.line, reader.column
+		}
+	}
+}
+
+func (s *lintSuppression) contains(pos Position) bool {
+	if pos.Filename() != s.filename {
+		return false
+	}
+	line, column := pos.startLine, pos.startColumn
+	if line < s.startLine || line == s.startLine && column < s.startColumn {
+		return false
+	}
+	return s.endLine == 0 || line < s.endLine || line == s.endLine && column <= s.endColumn
+}
+
+// isSuppressed returns true if p is in a form that
+// suppresses p's rule, and marks the suppression as used.
+func isSuppressed(p LintProblem) bool {
+	if _, ok := lintRules[p.rule]; !ok || p.rule == "unused-suppression" {
+		return false
+	}
+	res := false
+	for _, s := range lintSuppressions {
+		if !s.contains(p.pos) {
+			continue
+		}
+		if s.rules == nil {
+			s.used[""] = true
+			res = true
+			continue
+		}
+		for _, rule := range s.rules {
+			if rule == p.rule {
+				s.used[rule] = true
+				res = true
+			}
+		}
+	}
+	return res
+}
+
+// WarnOnUnusedSuppressions reports suppressions of rules
+// that found no problems in the forms they apply to.
+func WarnOnUnusedSuppressions() {
+	for _, s := range lintSuppressions {
+		if s.rules == nil && !s.used[""] {
+			printParseWarning(s.pos, "unused-suppression", "unused suppression")
+		}
+		for _, rule := range s.rules {
+			if !s.used[rule] {
+				printParseWarning(s.pos, "unused-suppression", "unused suppression of :"+rule)
+			}
+		}
+	}
+	lintSuppressions = nil
+}
+
+// lintProblemFromError returns the problem described by err,
+// as thrown by the reader, the parser or macros run by it.
+func lintProblemFromError(err error) (LintProblem, bool) {
+	switch err := err.(type) {
+	case ReadError:
+		pos := Position{
+			filename:    err.filename,
+			startLine:   err.line,
+			startColumn: err.column,
+		}
+		return LintProblem{pos: pos, phase: "Read", rule: "read-error", severity: LINT_ERROR, msg: err.msg}, true
+	case *ParseError:
+		var pos Position
+		if info := err.obj.GetInfo(); info != nil {
+			pos = info.Position
+		}
+		return LintProblem{pos: pos, phase: "Parse", rule: "parse-error", severity: LINT_ERROR, msg: err.msg}, true
+	case *EvalError:
+		pos := err.pos
+		if len(err.rt.callstack.frames) > 0 {
+			pos = err.rt.callstack.frames[0].traceable.Pos()
+		}
+		return LintProblem{pos: pos, phase: "Eval", rule: "eval-error", severity: LINT_ERROR, msg: err.msg}, true
+	case *ExInfo:
+		var pos Position
+		_, obj := err.Get(KEYWORDS.data)
+		data, ok := obj.(Map)
+		if !ok {
+			data = EmptyArrayMap()
+		}
+		if ok, form := data.Get(KEYWORDS.form); ok && form.GetInfo() != nil {
+			pos = form.GetInfo().Pos()
+		}
+		p := LintProblem{pos: pos, phase: "Exception", rule: "exception", severity: LINT_ERROR}
+		// Problems found by macros have a prefix like "Parse warning".
+		if ok, prefix := data.Get(KEYWORDS._prefix); ok {
+			p.phase = prefix.ToString(false)
+			if i := strings.LastIndexByte(p.phase, ' '); i > 0 {
+				if p.phase[i+1:] == "warning" {
+					p.severity = LINT_WARNING
+				}
+				p.phase = p.phase[:i]
+			}
+		}
+		// Rules given as anything but keywords are ignored.
+		if ok, rule := data.Get(MakeKeyword("_rule")); ok {
+			if k, ok := rule.(Keyword); ok {
+				p.rule = k.Name()
+			}
+		}
+		_, msg := err.Get(KEYWORDS.message)
+		p.msg = msg.ToString(false)
+		return p, true
+	}
+	return LintProblem{}, false
+}
+
+// printProcessError prints err, which stopped processing of the input.
+// When linting, it's reported like other problems.
+func printProcessError(err error) {
+	if LINTER_MODE {
+		if p, ok := lintProblemFromError(err); ok {
+			reportLintProblem(p)
+			return
+		}
+	}
+	fmt.Fprintln(Stderr, err)
+}
+
+// PrintLintProblems prints problems collected in LINT_FORMAT
+// other than "text" to w.
+func PrintLintProblems(w io.Writer) error {
+	switch LINT_FORMAT {
+	case "json":
+		return printLintProblemsJSON(w)
+	case "sarif":
+		return printLintProblemsSARIF(w)
+	}
+	return nil
+}
+
+func printLintProblemsJSON(w io.Writer) error {
+	problems := make([]lintProblemJSON, len(lintProblems))
+	for i, p := range lintProblems {
+		endLine, endColumn := p.endPosition()
+		problems[i] = lintProblemJSON{
+			File:        p.pos.Filename(),
+			Rule:        p.Rule(),
+			Severity:    p.Severity(),
+			Message:     p.msg,
+			StartLine:   p.pos.startLine,
+			StartColumn: p.pos.startColumn,
+			EndLine:     endLine,
+			EndColumn:   endColumn,
+		}
+	}
+	return writeJSON(w, problems)
+}
+
+// printLintProblemsSARIF prints the problems as a SARIF 2.1.0 log
+// (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html).
+func printLintProblemsSARIF(w io.Writer) error {
+	type (
+		m    = map[string]interface{}
+		list = []interface{}
+	)
+	rules := list{}
+	ruleIndex := make(map[string]int)
+	results := list{}
+	for _, p := range lintProblems {
+		rule := p.Rule()
+		if _, ok := ruleIndex[rule]; !ok {
+			ruleIndex[rule] = len(rules)
+			level := sarifLevel(p.severity)
+			if s, ok := lintRules[rule]; ok && s != LINT_OFF {
+				level = sarifLevel(s)
+			}
+			rules = append(rules, m{
+				"id":                   rule,
+				"defaultConfiguration": m{"level": level},
+			})
+		}
+		// SARIF requires positive line and column numbers,
+		// and its endColumn is exclusive.
+		region := m{"startLine": 1}
+		if p.pos.startLine > 0 {
+			endLine, endColumn := p.endPosition()
+			region = m{"startLine": p.pos.startLine, "endLine": endLine}
+			if p.pos.startColumn > 0 && endColumn > 0 {
+				region["startColumn"] = p.pos.startColumn
+				region["endColumn"] = endColumn + 1
+			}
+		}
+		results = append(results, m{
+			"ruleId":    rule,
+			"ruleIndex": ruleIndex[rule],
+			"level":     sarifLevel(p.severity),
+			"message":   m{"text": p.msg},
+			"locations": list{
+				m{"physicalLocation": m{
+					"artifactLocation": m{"uri": filepath.ToSlash(p.pos.Filename())},
+					"region":           region,
+				}},
+			},
+		})
+	}
+	return writeJSON(w, m{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": list{
+			m{
+				"tool": m{"driver": m{
+					"name":           "joker",
+					"version":        VERSION,
+					"informationUri": "https://github.com/candid82/joker",
+					"rules":          rules,
+				}},
+				"results": results,
+			},
+		},
+	})
+}
+
+func sarifLevel(s LintSeverity) string {
+	if s == LINT_INFO {
+		return "note"
+	}
+	return s.String()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// lintIndexVar is what the linter knows about a var
+	// defined in a project file.
+	lintIndexVar struct {
+		Private bool `json:"private,omitempty"`
+		// Fn is true if the var is a fn or a macro with known arities:
+		// numbers of args of the fixed ones, and the minimal number
+		// of args of the variadic one (-1 if there is none).
+		Fn       bool  `json:"fn,omitempty"`
+		Arities  []int `json:"arities,omitempty"`
+		Variadic int   `json:"variadic"`
+	}
+
+	lintIndexFile struct {
+		// sha256 of the file's content when it was indexed.
+		Hash string `json:"hash"`
+		// Vars defined in the file, by namespace and var name.
+		Namespaces map[string]map[string]*lintIndexVar `json:"namespaces"`
+	}
+
+	// lintIndex is the index of the vars defined in the files
+	// of a project, used to check references to vars of
+	// other namespaces (see CheckLintReferences).
+	lintIndex struct {
+		// Cache file the index is saved to.
+		path string
+		// Indexed files by absolute file name.
+		Files map[string]*lintIndexFile `json:"files"`
+		// Vars of all indexed files, by namespace and var name.
+		namespaces map[string]map[string]*lintIndexVar
+	}
+
+	// lintReference is a reference to a var of another namespace
+	// that is checked once all files of the project are indexed.
+	lintReference struct {
+		pos      Position
+		ns, name string
+		// Number of args passed if the var is called, -1 otherwise.
+		args int
+		// The namespace didn't exist when the reference was parsed,
+		// so it's an error unless the namespace is indexed.
+		unresolved bool
+	}
+)
+
+var (
+	// LINT_INDEX is the index of the project being linted,
+	// or nil if references to other namespaces aren't checked.
+	LINT_INDEX *lintIndex
+	// Vars defined by the file being linted.
+	lintDefs       []*Var
+	lintReferences []lintReference
+	// Namespaces created for references to namespaces that don't
+	// exist (see lintUnresolvedNamespace).
+	lintUnresolvedNamespaces map[*Namespace]bool
+)
+
+const lintIndexExt = ".jki"
+
+// lintIndexPath returns the name of the cache file of the index
+// of the project in dir, which is kept in the lib cache directory.
+func lintIndexPath(dir string) string {
+	h := sha256.New()
+	for _, s := range []string{VERSION, dir, fmt.Sprint(DIALECT)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return filepath.Join(libCacheDir(), hex.EncodeToString(h.Sum(nil))+lintIndexExt)
+}
+
+func newLintIndex(path string) *lintIndex {
+	return &lintIndex{
+		path:       path,
+		Files:      make(map[string]*lintIndexFile),
+		namespaces: make(map[string]map[string]*lintIndexVar),
+	}
+}
+
+// NewLintIndex makes the linter index the files of the project in dir
+// as they are linted, replacing the project's cached index.
+func NewLintIndex(dir string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	LINT_INDEX = newLintIndex(lintIndexPath(abs))
+}
+
+// LoadLintIndex loads the cached index of the project filename belongs to,
+// that is of the closest directory containing filename that was linted
+// with --working-dir. Entries of files changed since then are dropped.
+// Nothing is loaded if there is no such index.
+func LoadLintIndex(filename string) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		path := lintIndexPath(dir)
+		if data, err := os.ReadFile(path); err == nil {
+			index := newLintIndex(path)
+			if json.Unmarshal(data, index) != nil {
+				return
+			}
+			for name, file := range index.Files {
+				if name == abs || file.Hash != lintFileHash(name) {
+					delete(index.Files, name)
+					continue
+				}
+				index.merge(file)
+			}
+			LINT_INDEX = index
+			return
+		}
+		if filepath.Dir(dir) == dir {
+			return
+		}
+	}
+}
+
+func lintFileHash(filename string) string {
+	hash, err := fileHash(filename)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash)
+}
+
+func (index *lintIndex) merge(file *lintIndexFile) {
+	for nsName, vars := range file.Namespaces {
+		nsVars := index.namespaces[nsName]
+		if nsVars == nil {
+			nsVars = make(map[string]*lintIndexVar)
+			index.namespaces[nsName] = nsVars
+		}
+		for name, v := range vars {
+			nsVars[name] = v
+		}
+	}
+}
+
+func noteLintDef(vr *Var) {
+	if LINT_INDEX != nil {
+		lintDefs = append(lintDefs, vr)
+	}
+}
+
+// noteLintReference records a reference to vr (a call with args args
+// if args >= 0) if vr belongs to another namespace defined by the project.
+// Vars with values come from Joker's own namespaces and are skipped.
+func noteLintReference(vr *Var, pos Position, args int) {
+	if LINT_INDEX == nil || vr.ns == GLOBAL_ENV.CurrentNamespace() || vr.ns == GLOBAL_ENV.CoreNamespace || vr.Value != nil {
+		return
+	}
+	lintReferences = append(lintReferences, lintReference{
+		pos:  pos,
+		ns:   vr.ns.Name.Name(),
+		name: vr.name.Name(),
+		args: args,
+	})
+}
+
+// lintUnresolvedNamespace returns a namespace for sym, a symbol qualified
+// with a namespace that doesn't exist, if the project's index is used:
+// the namespace may be defined by another file of the project, which
+// is only known once all files are indexed. Returns nil otherwise.
+func lintUnresolvedNamespace(sym Symbol) *Namespace {
+	if LINT_INDEX == nil || sym.ns == nil {
+		return nil
+	}
+	ns := GLOBAL_ENV.EnsureSymbolIsNamespace(MakeSymbol(*sym.ns))
+	if lintUnresolvedNamespaces == nil {
+		lintUnresolvedNamespaces = make(map[*Namespace]bool)
+	}
+	lintUnresolvedNamespaces[ns] = true
+	return ns
+}
+
+// noteUnresolvedLintReference records a reference to vr, a var of
+// a namespace returned by lintUnresolvedNamespace, that is reported
+// as unresolved unless its namespace is indexed. It returns false
+// if vr doesn't belong to such a namespace.
+func noteUnresolvedLintReference(vr *Var, pos Position) bool {
+	if !lintUnresolvedNamespaces[vr.ns] {
+		return false
+	}
+	lintReferences = append(lintReferences, lintReference{
+		pos:        pos,
+		ns:         vr.ns.Name.Name(),
+		name:       vr.name.Name(),
+		args:       -1,
+		unresolved: true,
+	})
+	return true
+}
+
+func makeLintIndexVar(vr *Var) *lintIndexVar {
+	res := &lintIndexVar{
+		Private:  vr.isPrivate,
+		Variadic: -1,
+	}
+	if fn, ok := vr.expr.(*FnExpr); ok {
+		// Macros take &form and &env in addition to their args.
+		implicit := 0
+		if vr.isMacro {
+			implicit = 2
+		}
+		res.Fn = true
+		for _, arity := range fn.arities {
+			res.Arities = append(res.Arities, len(arity.args)-implicit)
+		}
+		if fn.variadic != nil {
+			res.Variadic = len(fn.variadic.args) - 1 - implicit
+		}
+	}
+	return res
+}
+
+func (v *lintIndexVar) accepts(args int) bool {
+	if !v.Fn || v.Variadic >= 0 && args >= v.Variadic {
+		return true
+	}
+	for _, n := range v.Arities {
+		if n == args {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexLintedFile adds the vars defined by filename, which has just been
+// linted, to the index, unless processing it failed with processErr.
+// Vars the linter created for unresolved symbols are indexed
+// without arities, as they may be defined by unknown macros.
+func IndexLintedFile(filename string, processErr error) {
+	defs := lintDefs
+	lintDefs = nil
+	if LINT_INDEX == nil || processErr != nil {
+		return
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return
+	}
+	file := &lintIndexFile{
+		Hash:       lintFileHash(filename),
+		Namespaces: make(map[string]map[string]*lintIndexVar),
+	}
+	for _, vr := range defs {
+		nsName := vr.ns.Name.Name()
+		vars := file.Namespaces[nsName]
+		if vars == nil {
+			vars = make(map[string]*lintIndexVar)
+			file.Namespaces[nsName] = vars
+		}
+		vars[vr.name.Name()] = makeLintIndexVar(vr)
+	}
+	LINT_INDEX.Files[abs] = file
+	LINT_INDEX.merge(file)
+}
+
+// CheckLintReferences checks the references to vars of other namespaces
+// found so far against the index: that referenced vars exist and are
+// public, and that calls pass them an accepted number of args.
+// References to namespaces that aren't indexed aren't checked,
+// unless the namespaces didn't exist when they were parsed.
+func CheckLintReferences() {
+	refs := lintReferences
+	lintReferences = nil
+	if LINT_INDEX == nil {
+		return
+	}
+	for _, ref := range refs {
+		name := ref.ns + "/" + ref.name
+		vars := LINT_INDEX.namespaces[ref.ns]
+		if vars == nil {
+			if ref.unresolved {
+				printParseError(ref.pos, "unresolved-symbol", "Unable to resolve symbol: "+name)
+			}
+			continue
+		}
+		v := vars[ref.name]
+		switch {
+		case ref.args >= 0:
+			if v != nil && !v.accepts(ref.args) {
+				printParseWarning(ref.pos, "wrong-arity", fmt.Sprintf("Wrong number of args (%d) passed to %s", ref.args, name))
+			}
+		case v == nil:
+			printParseWarning(ref.pos, "unresolved-var", "No such var: "+name)
+		case v.Private:
+			printParseWarning(ref.pos, "private-var", "Var "+name+" is not public")
+		}
+	}
+}
+
+// SaveLintIndex writes the index to its cache file.
+func SaveLintIndex() error {
+	if LINT_INDEX == nil {
+		return nil
+	}
+	data, err := json.Marshal(LINT_INDEX)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(LINT_INDEX.path)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), LINT_INDEX.path)
+}
@@ -11,6 +11,7 @@ type List struct {
 }
 
 func NewList(first Object, rest *List) *List {
+	chargeAlloc(allocElem)
 	result := List{
 		first: first,
 		rest:  rest,
@@ -59,6 +59,12 @@ func mapEquals(m Map, other interface{}) bool {
 	if m == other {
 		return true
 	}
+	if _, ok := other.(*Record); ok {
+		// Records are only equal to records of the same type (see Record.Equals).
+		if _, ok := m.(*Record); !ok {
+			return false
+		}
+	}
 	switch otherMap := other.(type) {
 	case Nil:
 		return false
@@ -16,6 +16,9 @@ type (
 		isUsed         bool
 		isGloballyUsed bool
 		hash           uint32
+		// Interpreter the namespace belongs to, nil if it's shared
+		// (see Isolate).
+		owner *Runtime
 	}
 )
 
@@ -66,7 +69,15 @@ func (ns *Namespace) MaybeLazy(doc string) {
 	if ns.Lazy != nil {
 		lazyFn := ns.Lazy
 		ns.Lazy = nil
-		lazyFn()
+		// Lazily initialized namespaces are shared, even when
+		// code of an interpreter initializes them (see Isolate).
+		rt, owner := RT, RT.owner
+		rt.owner = nil
+		func() {
+			defer func() { rt.owner = owner }()
+			lazyFn()
+		}()
+		sealNamespace(ns)
 		if VerbosityLevel > 0 {
 			fmt.Fprintf(Stderr, "NamespaceFor: Lazily initialized %s for %s\n", *ns.Name.name, doc)
 		}
@@ -114,7 +125,7 @@ func (ns *Namespace) Intern(sym Symbol) *Var {
 		if LINTER_TYPES[sym.name] {
 			msg := fmt.Sprintf("Expecting var, but %s is a type", *sym.name)
 			pos := sym.GetInfo().Pos()
-			printParseWarning(pos, msg)
+			printParseWarning(pos, "var-is-type", msg)
 		}
 	}
 	sym.meta = nil
@@ -135,7 +146,7 @@ func (ns *Namespace) Intern(sym Symbol) *Var {
 			}
 			ns.mappings[sym.name] = newVar
 			if !strings.HasPrefix(ns.Name.Name(), "joker.") {
-				printParseWarning(GetPosition(sym), fmt.Sprintf("WARNING: %s already refers to: %s in namespace %s, being replaced by: %s\n",
+				printParseWarning(GetPosition(sym), "replaced-var", fmt.Sprintf("WARNING: %s already refers to: %s in namespace %s, being replaced by: %s\n",
 					sym.ToString(false), existingVar.ToString(false), ns.Name.ToString(false), newVar.ToString(false)))
 			}
 			return newVar
@@ -146,9 +157,9 @@ func (ns *Namespace) Intern(sym Symbol) *Var {
 	if LINTER_MODE && existingVar.expr != nil && !existingVar.ns.Name.Equals(SYMBOLS.joker_core) {
 		if !isDeclaredInConfig(existingVar) {
 			if sym.GetInfo() == nil {
-				printParseWarning(existingVar.GetInfo().Pos(), "Subsequent duplicate def of "+existingVar.ToString(false))
+				printParseWarning(existingVar.GetInfo().Pos(), "duplicate-def", "Subsequent duplicate def of "+existingVar.ToString(false))
 			} else {
-				printParseWarning(sym.GetInfo().Pos(), "Duplicate def of "+existingVar.ToString(false))
+				printParseWarning(sym.GetInfo().Pos(), "duplicate-def", "Duplicate def of "+existingVar.ToString(false))
 			}
 		}
 	}
@@ -188,7 +199,7 @@ func (ns *Namespace) AddAlias(alias Symbol, namespace *Namespace) {
 	if existing != nil && existing != namespace {
 		msg := "Alias " + alias.ToString(false) + " already exists in namespace " + ns.Name.ToString(false) + ", aliasing " + existing.Name.ToString(false)
 		if LINTER_MODE {
-			printParseError(GetPosition(alias), msg)
+			printParseError(GetPosition(alias), "duplicate-alias", msg)
 			return
 		}
 		panic(RT.NewError(msg))
@@ -207,3 +218,70 @@ func (ns *Namespace) Mappings() map[*string]*Var {
 func (ns *Namespace) Aliases() map[*string]*Namespace {
 	return ns.aliases
 }
+
+// Isolate makes rt the runtime of an interpreter embedded in a Go
+// program (see pkg/joker). rt and the runtimes it spawns own the
+// namespaces they create with create-ns (in-ns, ns) and can only change
+// the namespaces they own: neither those of other interpreters nor shared
+// ones, such as joker.core and libs. Namespaces created by loading libs
+// are shared once loaded.
+func (rt *Runtime) Isolate() {
+	rt.owner = rt
+}
+
+// OwnNamespace makes ns belong to the interpreter of rt (see Isolate).
+func (rt *Runtime) OwnNamespace(ns *Namespace) {
+	ns.owner = rt.owner
+}
+
+// IsNamespaceOwned returns true if ns belongs to an interpreter.
+func IsNamespaceOwned(ns *Namespace) bool {
+	return ns.owner != nil
+}
+
+// RemoveOwnNamespaces removes the namespaces that belong to
+// the interpreter of rt from the environment and *loaded-libs*.
+func (rt *Runtime) RemoveOwnNamespaces() {
+	for name, ns := range GLOBAL_ENV.Namespaces {
+		if ns.owner == nil || ns.owner != rt.owner {
+			continue
+		}
+		delete(GLOBAL_ENV.Namespaces, name)
+		if libs, ok := GLOBAL_ENV.libs.Value.(Set); ok {
+			GLOBAL_ENV.libs.Value = libs.Disjoin(ns.Name)
+		}
+	}
+}
+
+// shareNewNamespaces calls load, which loads a lib, and makes the
+// namespaces the interpreter of rt created meanwhile shared.
+// They stay owned if load panics, so that they can be removed.
+func (rt *Runtime) shareNewNamespaces(load func()) {
+	if rt.owner == nil {
+		load()
+		return
+	}
+	existing := make(map[*Namespace]bool, len(GLOBAL_ENV.Namespaces))
+	for _, ns := range GLOBAL_ENV.Namespaces {
+		existing[ns] = true
+	}
+	load()
+	for _, ns := range GLOBAL_ENV.Namespaces {
+		if !existing[ns] && ns.owner == rt.owner {
+			ns.owner = nil
+		}
+	}
+}
+
+// checkNamespaceOwner panics if rt can't change ns (see Isolate):
+// if ns belongs to another interpreter, or if ns is shared
+// and rt belongs to an interpreter.
+func (rt *Runtime) checkNamespaceOwner(ns *Namespace) {
+	if ns == nil || ns.owner == rt.owner {
+		return
+	}
+	if ns.owner == nil {
+		panic(rt.NewError("Namespace " + ns.Name.ToString(false) + " is shared and can't be changed by an interpreter"))
+	}
+	panic(rt.NewError("Namespace " + ns.Name.ToString(false) + " belongs to another interpreter"))
+}
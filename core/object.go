@@ -1,5 +1,5 @@
-//go:generate go run gen/gen_types.go assert Comparable Vec Char String Symbol Keyword *Regex Boolean Time Number Seqable Callable *Type Meta Int Double Stack Map Set Associative Reversible Named Comparator *Ratio *BigFloat *BigInt *Namespace *Var Error *Fn Deref *Atom Ref KVReduce Reduce Pending *File io.Reader io.Writer StringReader io.RuneReader *Channel CountedIndexed
-//go:generate go run gen/gen_types.go info *List *ArrayMapSeq *ArrayMap *HashMap *ExInfo *Fn *Var Nil *Ratio *BigInt *BigFloat Char Double Int Boolean Time Keyword *Regex Symbol String Comment *LazySeq *MappingSeq *ArraySeq *ConsSeq *NodeSeq *ArrayNodeSeq *MapSet *Vector *ArrayVector *VectorSeq *VectorRSeq
+//go:generate go run gen/gen_types.go assert Comparable Vec Char String Symbol Keyword *Regex Boolean Time UUID Number Seqable Callable *Type Meta Int Double Stack Map Set Associative Reversible Named Comparator *Ratio *BigFloat *BigInt *Namespace *Var Error *Fn Deref *Atom Ref KVReduce Reduce Pending *File io.Reader io.Writer StringReader io.RuneReader *Channel *Future *Promise CountedIndexed *Protocol Sorted Transient *TransientVector *TransientMap *TransientSet
+//go:generate go run gen/gen_types.go info *List *ArrayMapSeq *ArrayMap *HashMap *ExInfo *Fn *Var Nil *Ratio *BigInt *BigFloat Char Double Int Boolean Time UUID Keyword *Regex Symbol String Comment *LazySeq *MappingSeq *ArraySeq *ConsSeq *NodeSeq *ArrayNodeSeq *MapSet *Vector *ArrayVector *VectorSeq *VectorRSeq *SortedMap *SortedSet *SortedSeq
 //go:generate go run -tags gen_code gen_code/gen_code.go
 
 package core
@@ -39,6 +39,7 @@ type (
 		MetaHolder
 		name        string
 		reflectType reflect.Type
+		fields      []Keyword // Non-nil for types defined via defrecord or deftype
 	}
 	Object interface {
 		Equality
@@ -148,6 +149,12 @@ type (
 		InfoHolder
 		T time.Time
 	}
+	// UUID holds the lower case string form of a UUID,
+	// as read by #uuid.
+	UUID struct {
+		InfoHolder
+		S string
+	}
 	Var struct {
 		InfoHolder
 		MetaHolder
@@ -181,10 +188,23 @@ type (
 		rt *Runtime
 	}
 	RecurBindings []Object
-	Delay         struct {
+	// TailCall is a call of a Fn in tail position of a function body.
+	// Instead of being made, it's returned to Fn.Call, which makes it
+	// without growing the Go stack or the call stack.
+	TailCall struct {
+		fn   *Fn
+		args []Object
+		expr *CallExpr
+	}
+	Delay struct {
 		fn    Callable
 		value Object
 	}
+	// Reduced wraps the result of a reducing function to signal that
+	// the reduction should stop early.
+	Reduced struct {
+		value Object
+	}
 	Sequential interface {
 		sequential()
 	}
@@ -239,11 +259,19 @@ type (
 	}
 	Atom struct {
 		MetaHolder
-		value Object
+		value     Object
+		validator Object
+		watches   Map
+		// Incremented whenever value is set; see compareAndSet.
+		version uint64
 	}
 	Deref interface {
 		Deref() Object
 	}
+	BlockingDeref interface {
+		Deref
+		DerefWithTimeout(timeout time.Duration, timeoutValue Object) Object
+	}
 	Native interface {
 		Native() interface{}
 	}
@@ -258,79 +286,97 @@ type (
 		IsRealized() bool
 	}
 	Types struct {
-		Associative    *Type
-		Callable       *Type
-		Collection     *Type
-		Comparable     *Type
-		Comparator     *Type
-		Counted        *Type
-		CountedIndexed *Type
-		Deref          *Type
-		Channel        *Type
-		Error          *Type
-		Gettable       *Type
-		Indexed        *Type
-		IOReader       *Type
-		IOWriter       *Type
-		KVReduce       *Type
-		Reduce         *Type
-		Map            *Type
-		Meta           *Type
-		Named          *Type
-		Number         *Type
-		Pending        *Type
-		Ref            *Type
-		Reversible     *Type
-		Seq            *Type
-		Seqable        *Type
-		Sequential     *Type
-		Set            *Type
-		Stack          *Type
-		ArrayMap       *Type
-		ArrayMapSeq    *Type
-		ArrayNodeSeq   *Type
-		ArraySeq       *Type
-		MapSet         *Type
-		Atom           *Type
-		BigFloat       *Type
-		BigInt         *Type
-		Boolean        *Type
-		Time           *Type
-		Buffer         *Type
-		Char           *Type
-		ConsSeq        *Type
-		Delay          *Type
-		Double         *Type
-		EvalError      *Type
-		ExInfo         *Type
-		Fn             *Type
-		File           *Type
-		BufferedReader *Type
-		HashMap        *Type
-		Int            *Type
-		Keyword        *Type
-		LazySeq        *Type
-		List           *Type
-		MappingSeq     *Type
-		Namespace      *Type
-		Nil            *Type
-		NodeSeq        *Type
-		ParseError     *Type
-		Proc           *Type
-		ProcFn         *Type
-		Ratio          *Type
-		RecurBindings  *Type
-		Regex          *Type
-		String         *Type
-		Symbol         *Type
-		Type           *Type
-		Var            *Type
-		Vector         *Type
-		Vec            *Type
-		ArrayVector    *Type
-		VectorRSeq     *Type
-		VectorSeq      *Type
-		StringSeq      *Type
+		Associative     *Type
+		Callable        *Type
+		Collection      *Type
+		Comparable      *Type
+		Comparator      *Type
+		Counted         *Type
+		CountedIndexed  *Type
+		Deref           *Type
+		Channel         *Type
+		Error           *Type
+		Gettable        *Type
+		Indexed         *Type
+		IOReader        *Type
+		IOWriter        *Type
+		KVReduce        *Type
+		Reduce          *Type
+		Map             *Type
+		Meta            *Type
+		Named           *Type
+		Number          *Type
+		Object          *Type
+		Pending         *Type
+		Ref             *Type
+		Reversible      *Type
+		Seq             *Type
+		Seqable         *Type
+		Sequential      *Type
+		Set             *Type
+		Sorted          *Type
+		Stack           *Type
+		Transient       *Type
+		ArrayMap        *Type
+		ArrayMapSeq     *Type
+		ArrayNodeSeq    *Type
+		ArraySeq        *Type
+		MapSet          *Type
+		Atom            *Type
+		BigFloat        *Type
+		BigInt          *Type
+		Boolean         *Type
+		Time            *Type
+		UUID            *Type
+		Buffer          *Type
+		Char            *Type
+		ConsSeq         *Type
+		Delay           *Type
+		Double          *Type
+		EvalError       *Type
+		ExInfo          *Type
+		Fn              *Type
+		File            *Type
+		Future          *Type
+		BufferedReader  *Type
+		HashMap         *Type
+		Int             *Type
+		Keyword         *Type
+		LazySeq         *Type
+		LimitExceeded   *Type
+		List            *Type
+		MappingSeq      *Type
+		Namespace       *Type
+		Nil             *Type
+		NodeSeq         *Type
+		ParseError      *Type
+		Proc            *Type
+		ProcFn          *Type
+		Promise         *Type
+		Protocol        *Type
+		Ratio           *Type
+		Record          *Type
+		Reduced         *Type
+		RecurBindings   *Type
+		StackOverflow   *Type
+		TailCall        *Type
+		Regex           *Type
+		SortedMap       *Type
+		SortedSeq       *Type
+		SortedSet       *Type
+		String          *Type
+		Symbol          *Type
+		TransientMap    *Type
+		TransientSet    *Type
+		TransientVector *Type
+		Type            *Type
+		Var             *Type
+		Vector          *Type
+		Vec             *Type
+		ArrayVector     *Type
+		VectorRSeq      *Type
+		VectorSeq       *Type
+		StringSeq       *Type
 	}
 )
 
@@ -418,7 +464,7 @@ func MakeKeyword(nsname string) Keyword {
 }
 
 func PanicArity(n int) {
-	name := RT.currentExpr.(Traceable).Name()
+	name := RT.currentName()
 	panic(RT.NewError(fmt.Sprintf("Wrong number of args (%d) passed to %s", n, name)))
 }
 
@@ -439,7 +485,7 @@ func rangeString(min, max int) string {
 }
 
 func PanicArityMinMax(n, min, max int) {
-	name := RT.currentExpr.(Traceable).Name()
+	name := RT.currentName()
 	panic(RT.NewError(fmt.Sprintf("Wrong number of args (%d) passed to %s; expects %s", n, name, rangeString(min, max))))
 }
 
@@ -453,8 +499,8 @@ func CheckArity(args []Object, min int, max int) {
 func getMap(k Object, args []Object) Object {
 	CheckArity(args, 1, 2)
 	switch m := args[0].(type) {
-	case Map:
-		ok, v := m.Get(k)
+	case Map, *TypeInstance:
+		ok, v := m.(Gettable).Get(k)
 		if ok {
 			return v
 		}
@@ -554,6 +600,45 @@ func (a *Atom) Deref() Object {
 	return a.value
 }
 
+func (a *Atom) validate(value Object) {
+	if a.validator != nil && !ToBool(a.validator.(Callable).Call([]Object{value})) {
+		panic(RT.NewError("Invalid reference state"))
+	}
+}
+
+// set validates newValue, makes it the value of the atom
+// and notifies the watches of the change.
+func (a *Atom) set(newValue Object) {
+	a.validate(newValue)
+	a.store(newValue)
+}
+
+// compareAndSet is like set, but only sets the value if it hasn't
+// been set since version was read, returning whether it has.
+// Validators and the functions passed to swap! may release the GIL,
+// letting other goroutines change the atom in the meantime.
+func (a *Atom) compareAndSet(version uint64, newValue Object) bool {
+	a.validate(newValue)
+	if a.version != version {
+		return false
+	}
+	a.store(newValue)
+	return true
+}
+
+func (a *Atom) store(newValue Object) {
+	oldValue := a.value
+	a.value = newValue
+	a.version++
+	if a.watches == nil {
+		return
+	}
+	for iter := a.watches.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		EnsureObjectIsCallable(p.Value, "Watch function must be callable, got %s").Call([]Object{p.Key, a, oldValue, newValue})
+	}
+}
+
 func (d *Delay) ToString(escape bool) string {
 	return "#object[Delay]"
 }
@@ -593,6 +678,43 @@ func (d *Delay) IsRealized() bool {
 	return d.value != nil
 }
 
+func (r *Reduced) ToString(escape bool) string {
+	return "#object[Reduced {:val " + r.value.ToString(escape) + "}]"
+}
+
+func (r *Reduced) Equals(other interface{}) bool {
+	return r == other
+}
+
+func (r *Reduced) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (r *Reduced) GetType() *Type {
+	return TYPE.Reduced
+}
+
+func (r *Reduced) Hash() uint32 {
+	return HashPtr(uintptr(unsafe.Pointer(r)))
+}
+
+func (r *Reduced) WithInfo(info *ObjectInfo) Object {
+	return r
+}
+
+func (r *Reduced) Deref() Object {
+	return r.value
+}
+
+// unreduced returns the value wrapped by obj if it is Reduced, and
+// reports whether it was.
+func unreduced(obj Object) (Object, bool) {
+	if r, ok := obj.(*Reduced); ok {
+		return r.value, true
+	}
+	return obj, false
+}
+
 func (t *Type) ToString(escape bool) string {
 	return t.name
 }
@@ -633,6 +755,26 @@ func (rb RecurBindings) Hash() uint32 {
 	return 0
 }
 
+func (tc *TailCall) ToString(escape bool) string {
+	return "#object[TailCall]"
+}
+
+func (tc *TailCall) Equals(other interface{}) bool {
+	return false
+}
+
+func (tc *TailCall) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (tc *TailCall) GetType() *Type {
+	return TYPE.TailCall
+}
+
+func (tc *TailCall) Hash() uint32 {
+	return 0
+}
+
 func (exInfo *ExInfo) ToString(escape bool) string {
 	return exInfo.Error()
 }
@@ -704,15 +846,39 @@ func (fn *Fn) Hash() uint32 {
 	return HashPtr(uintptr(unsafe.Pointer(fn)))
 }
 
+// Call calls fn with args. Tail calls made by the body of fn
+// (see TailCall) are made here, in a loop, reusing the call stack
+// frame of fn.
 func (fn *Fn) Call(args []Object) Object {
+	arity, env := fn.arity(args)
+	rt := RT
+	parentExpr := rt.currentExpr
+	rt.pushFrame()
+	defer rt.popFrame()
+	for {
+		res := arity.evalFnBody(env)
+		tc, ok := res.(*TailCall)
+		if !ok {
+			rt.currentExpr = parentExpr
+			return res
+		}
+		rt.currentExpr = tc.expr
+		rt.checkLimits()
+		arity, env = tc.fn.arity(tc.args)
+		rt.callstack.tailCall(tc.expr)
+	}
+}
+
+// arity returns the arity of fn to call with args
+// and the local environment to evaluate its body in.
+func (fn *Fn) arity(args []Object) (*FnArityExpr, *LocalEnv) {
 	min := math.MaxInt32
 	max := -1
-	for _, arity := range fn.fnExpr.arities {
+	for i := range fn.fnExpr.arities {
+		arity := &fn.fnExpr.arities[i]
 		a := len(arity.args)
 		if a == len(args) {
-			RT.pushFrame()
-			defer RT.popFrame()
-			return evalLoop(arity.body, fn.env.addFrame(args))
+			return arity, fn.env.addFrame(args)
 		}
 		if min > a {
 			min = a
@@ -746,9 +912,7 @@ func (fn *Fn) Call(args []Object) Object {
 		vargs[i] = args[i]
 	}
 	vargs[len(vargs)-1] = restArgs
-	RT.pushFrame()
-	defer RT.popFrame()
-	return evalLoop(v.body, fn.env.addFrame(vargs))
+	return v, fn.env.addFrame(vargs)
 }
 
 func compare(c Callable, a, b Object) int {
@@ -1189,6 +1353,10 @@ func MakeTime(t time.Time) Time {
 	return Time{T: t}
 }
 
+func MakeUUID(s string) UUID {
+	return UUID{S: s}
+}
+
 func MakeDouble(d float64) Double {
 	return Double{D: d}
 }
@@ -1365,6 +1533,42 @@ func (t Time) Compare(other Object) int {
 	return -1
 }
 
+func (u UUID) ToString(escape bool) string {
+	if escape {
+		return "#uuid " + escapeString(u.S)
+	}
+	return u.S
+}
+
+func (u UUID) Equals(other interface{}) bool {
+	switch other := other.(type) {
+	case UUID:
+		return u.S == other.S
+	default:
+		return false
+	}
+}
+
+func (u UUID) GetType() *Type {
+	return TYPE.UUID
+}
+
+func (u UUID) Native() interface{} {
+	return u.S
+}
+
+func (u UUID) Hash() uint32 {
+	h := getHash()
+	h.Write([]byte("#uuid"))
+	h.Write([]byte(u.S))
+	return h.Sum32()
+}
+
+func (u UUID) Compare(other Object) int {
+	u2 := EnsureObjectIsUUID(other, "Cannot compare UUID: %s")
+	return strings.Compare(u.S, u2.S)
+}
+
 func (k Keyword) ToString(escape bool) string {
 	if k.ns != nil {
 		return ":" + *k.ns + "/" + *k.name
@@ -1518,6 +1722,7 @@ func (s String) Format(w io.Writer, indent int) int {
 }
 
 func MakeString(s string) String {
+	chargeAlloc(len(s))
 	return String{S: s}
 }
 
@@ -1680,9 +1885,16 @@ func (x RecurBindings) WithInfo(info *ObjectInfo) Object {
 	return x
 }
 
+func (x *TailCall) WithInfo(info *ObjectInfo) Object {
+	return x
+}
+
 func IsEqualOrImplements(abstractType *Type, concreteType *Type) bool {
 	if abstractType.reflectType.Kind() == reflect.Interface {
 		return concreteType.reflectType.Implements(abstractType.reflectType)
+	} else if abstractType.isDefinedType() {
+		// All record types share the same Go type, and so do all deftype types.
+		return concreteType == abstractType
 	} else {
 		return concreteType.reflectType == abstractType.reflectType
 	}
@@ -1720,7 +1932,7 @@ func RegRefType(name string, inst interface{}, doc string) *Type {
 	}
 	meta := MakeMeta(nil, "(Concrete reference type)"+doc, "1.0")
 	meta.Add(KEYWORDS.name, MakeString(name))
-	t := &Type{MetaHolder{meta}, name, reflect.TypeOf(inst)}
+	t := &Type{MetaHolder: MetaHolder{meta}, name: name, reflectType: reflect.TypeOf(inst)}
 	TYPES[STRINGS.Intern(name)] = t
 	return t
 }
@@ -1731,7 +1943,7 @@ func RegType(name string, inst interface{}, doc string) *Type {
 	}
 	meta := MakeMeta(nil, "(Concrete type)"+doc, "1.0")
 	meta.Add(KEYWORDS.name, MakeString(name))
-	t := &Type{MetaHolder{meta}, name, reflect.TypeOf(inst).Elem()}
+	t := &Type{MetaHolder: MetaHolder{meta}, name: name, reflectType: reflect.TypeOf(inst).Elem()}
 	TYPES[STRINGS.Intern(name)] = t
 	return t
 }
@@ -1742,7 +1954,7 @@ func RegInterface(name string, inst interface{}, doc string) *Type {
 	}
 	meta := MakeMeta(nil, "(Interface type)"+doc, "1.0")
 	meta.Add(KEYWORDS.name, MakeString(name))
-	t := &Type{MetaHolder{meta}, name, reflect.TypeOf(inst).Elem()}
+	t := &Type{MetaHolder: MetaHolder{meta}, name: name, reflectType: reflect.TypeOf(inst).Elem()}
 	TYPES[STRINGS.Intern(name)] = t
 	return t
 }
@@ -1812,6 +2024,9 @@ func CountedIndexedKvreduce(v CountedIndexed, c Callable, init Object) Object {
 	res := init
 	for i := 0; i < v.Count(); i++ {
 		res = c.Call([]Object{res, Int{I: i}, v.At(i)})
+		if r, ok := unreduced(res); ok {
+			return r
+		}
 	}
 	return res
 }
@@ -1865,10 +2080,14 @@ func CountedIndexedReduce(v CountedIndexed, c Callable) Object {
 		args[1] = v.At(1)
 		acc := c.Call(args)
 		for i := 2; i < v.Count(); i++ {
+			if r, ok := unreduced(acc); ok {
+				return r
+			}
 			args[0] = acc
 			args[1] = v.At(i)
 			acc = c.Call(args)
 		}
+		acc, _ = unreduced(acc)
 		return acc
 	}
 }
@@ -1883,10 +2102,14 @@ func CountedIndexedReduceInit(v CountedIndexed, c Callable, init Object) Object
 		args[1] = v.At(0)
 		acc := c.Call(args)
 		for i := 1; i < v.Count(); i++ {
+			if r, ok := unreduced(acc); ok {
+				return r
+			}
 			args[0] = acc
 			args[1] = v.At(i)
 			acc = c.Call(args)
 		}
+		acc, _ = unreduced(acc)
 		return acc
 	}
 }
@@ -32,6 +32,7 @@ func init() {
 		Meta:           RegInterface("Meta", (*Meta)(nil), ""),
 		Named:          RegInterface("Named", (*Named)(nil), ""),
 		Number:         RegInterface("Number", (*Number)(nil), ""),
+		Object:         RegInterface("Object", (*Object)(nil), ""),
 		Pending:        RegInterface("Pending", (*Pending)(nil), ""),
 		Ref:            RegInterface("Ref", (*Ref)(nil), ""),
 		Reversible:     RegInterface("Reversible", (*Reversible)(nil), ""),
@@ -39,7 +40,9 @@ func init() {
 		Seqable:        RegInterface("Seqable", (*Seqable)(nil), ""),
 		Sequential:     RegInterface("Sequential", (*Sequential)(nil), ""),
 		Set:            RegInterface("Set", (*Set)(nil), ""),
+		Sorted:         RegInterface("Sorted", (*Sorted)(nil), ""),
 		Stack:          RegInterface("Stack", (*Stack)(nil), ""),
+		Transient:      RegInterface("Transient", (*Transient)(nil), ""),
 		ArrayMap:       RegRefType("ArrayMap", (*ArrayMap)(nil), ""),
 		ArrayMapSeq:    RegRefType("ArrayMapSeq", (*ArrayMapSeq)(nil), ""),
 		ArrayNodeSeq:   RegRefType("ArrayNodeSeq", (*ArrayNodeSeq)(nil), ""),
@@ -50,6 +53,7 @@ func init() {
 		BigInt:         RegRefType("BigInt", (*BigInt)(nil), "Wraps the Go 'math/big.Int' type"),
 		Boolean:        RegType("Boolean", (*Boolean)(nil), "Wraps the Go 'bool' type"),
 		Time:           RegType("Time", (*Time)(nil), "Wraps the Go 'time.Time' type"),
+		UUID:           RegType("UUID", (*UUID)(nil), "A UUID, as read by #uuid"),
 		Buffer:         RegRefType("Buffer", (*Buffer)(nil), ""),
 		Char:           RegType("Char", (*Char)(nil), "Wraps the Go 'rune' type"),
 		ConsSeq:        RegRefType("ConsSeq", (*ConsSeq)(nil), ""),
@@ -60,31 +64,45 @@ func init() {
 		ExInfo:         RegRefType("ExInfo", (*ExInfo)(nil), ""),
 		Fn:             RegRefType("Fn", (*Fn)(nil), "A callable function or macro implemented via Joker code"),
 		File:           RegRefType("File", (*File)(nil), ""),
+		Future:         RegRefType("Future", (*Future)(nil), "Holds the result of a computation running in a goroutine"),
 		BufferedReader: RegRefType("BufferedReader", (*BufferedReader)(nil), ""),
 		HashMap:        RegRefType("HashMap", (*HashMap)(nil), ""),
 		Int: RegType("Int", (*Int)(nil),
 			"Wraps the Go 'int' type, which is 32 bits wide on 32-bit hosts, 64 bits wide on 64-bit hosts, etc."),
-		Keyword:       RegType("Keyword", (*Keyword)(nil), "A possibly-namespace-qualified name prefixed by ':'"),
-		LazySeq:       RegRefType("LazySeq", (*LazySeq)(nil), ""),
-		List:          RegRefType("List", (*List)(nil), ""),
-		MappingSeq:    RegRefType("MappingSeq", (*MappingSeq)(nil), ""),
-		Namespace:     RegRefType("Namespace", (*Namespace)(nil), ""),
-		Nil:           RegType("Nil", (*Nil)(nil), "The 'nil' value"),
-		NodeSeq:       RegRefType("NodeSeq", (*NodeSeq)(nil), ""),
-		ParseError:    RegRefType("ParseError", (*ParseError)(nil), ""),
-		Proc:          RegRefType("Proc", (*Proc)(nil), "A callable function implemented via Go code"),
-		Ratio:         RegRefType("Ratio", (*Ratio)(nil), "Wraps the Go 'math.big/Rat' type"),
-		RecurBindings: RegRefType("RecurBindings", (*RecurBindings)(nil), ""),
-		Regex:         RegRefType("Regex", (*Regex)(nil), "Wraps the Go 'regexp.Regexp' type"),
-		String:        RegType("String", (*String)(nil), "Wraps the Go 'string' type"),
-		Symbol:        RegType("Symbol", (*Symbol)(nil), ""),
-		Type:          RegRefType("Type", (*Type)(nil), ""),
-		Var:           RegRefType("Var", (*Var)(nil), ""),
-		Vector:        RegRefType("Vector", (*Vector)(nil), ""),
-		Vec:           RegInterface("Vec", (*Vec)(nil), ""),
-		ArrayVector:   RegRefType("ArrayVector", (*ArrayVector)(nil), ""),
-		VectorRSeq:    RegRefType("VectorRSeq", (*VectorRSeq)(nil), ""),
-		VectorSeq:     RegRefType("VectorSeq", (*VectorSeq)(nil), ""),
-		StringSeq:     RegRefType("StringSeq", (*stringSeq)(nil), ""),
+		Keyword:         RegType("Keyword", (*Keyword)(nil), "A possibly-namespace-qualified name prefixed by ':'"),
+		LazySeq:         RegRefType("LazySeq", (*LazySeq)(nil), ""),
+		LimitExceeded:   RegRefType("LimitExceeded", (*LimitExceeded)(nil), "Raised when evaluation exceeds its time or step limit"),
+		List:            RegRefType("List", (*List)(nil), ""),
+		MappingSeq:      RegRefType("MappingSeq", (*MappingSeq)(nil), ""),
+		Namespace:       RegRefType("Namespace", (*Namespace)(nil), ""),
+		Nil:             RegType("Nil", (*Nil)(nil), "The 'nil' value"),
+		NodeSeq:         RegRefType("NodeSeq", (*NodeSeq)(nil), ""),
+		ParseError:      RegRefType("ParseError", (*ParseError)(nil), ""),
+		Proc:            RegRefType("Proc", (*Proc)(nil), "A callable function implemented via Go code"),
+		Promise:         RegRefType("Promise", (*Promise)(nil), "Holds a value that is delivered once, possibly by another goroutine"),
+		Protocol:        RegRefType("Protocol", (*Protocol)(nil), "A named set of methods dispatched on the type of their first argument"),
+		Ratio:           RegRefType("Ratio", (*Ratio)(nil), "Wraps the Go 'math.big/Rat' type"),
+		Record:          RegRefType("Record", (*Record)(nil), "The base type of all types defined via defrecord"),
+		Reduced:         RegRefType("Reduced", (*Reduced)(nil), "Wraps the result of a reduction that should terminate early"),
+		RecurBindings:   RegRefType("RecurBindings", (*RecurBindings)(nil), ""),
+		Regex:           RegRefType("Regex", (*Regex)(nil), "Wraps the Go 'regexp.Regexp' type"),
+		SortedMap:       RegRefType("SortedMap", (*SortedMap)(nil), ""),
+		SortedSeq:       RegRefType("SortedSeq", (*SortedSeq)(nil), ""),
+		SortedSet:       RegRefType("SortedSet", (*SortedSet)(nil), ""),
+		StackOverflow:   RegRefType("StackOverflow", (*StackOverflow)(nil), "Raised when the call stack gets deeper than *max-stack-depth*"),
+		String:          RegType("String", (*String)(nil), "Wraps the Go 'string' type"),
+		Symbol:          RegType("Symbol", (*Symbol)(nil), ""),
+		TailCall:        RegRefType("TailCall", (*TailCall)(nil), ""),
+		TransientMap:    RegRefType("TransientMap", (*TransientMap)(nil), ""),
+		TransientSet:    RegRefType("TransientSet", (*TransientSet)(nil), ""),
+		TransientVector: RegRefType("TransientVector", (*TransientVector)(nil), ""),
+		Type:            RegRefType("Type", (*Type)(nil), ""),
+		Var:             RegRefType("Var", (*Var)(nil), ""),
+		Vector:          RegRefType("Vector", (*Vector)(nil), ""),
+		Vec:             RegInterface("Vec", (*Vec)(nil), ""),
+		ArrayVector:     RegRefType("ArrayVector", (*ArrayVector)(nil), ""),
+		VectorRSeq:      RegRefType("VectorRSeq", (*VectorRSeq)(nil), ""),
+		VectorSeq:       RegRefType("VectorSeq", (*VectorSeq)(nil), ""),
+		StringSeq:       RegRefType("StringSeq", (*stringSeq)(nil), ""),
 	}
 }
@@ -43,6 +43,9 @@ type (
 		Bindings         map[*Binding]int
 		nextStringIndex  uint16
 		nextBindingIndex int
+		// Set when an object that can't be read back
+		// from its printed form is packed.
+		unpackable bool
 	}
 
 	PackHeader struct {
@@ -338,6 +341,9 @@ func packObject(obj Object, p []byte, env *PackEnv) []byte {
 		p = obj.Pack(p, env)
 		return p
 	default:
+		if !isReadable(obj) {
+			env.unpackable = true
+		}
 		p = append(p, NULL)
 		var buf bytes.Buffer
 		PrintObject(obj, &buf)
@@ -348,6 +354,35 @@ func packObject(obj Object, p []byte, env *PackEnv) []byte {
 	}
 }
 
+// isReadable returns true if reading the printed form of obj
+// gives an object equal to obj, with the same metadata.
+func isReadable(obj Object) bool {
+	if m, ok := obj.(Meta); ok && m.GetMeta() != nil && m.GetMeta().Count() > 0 {
+		return false
+	}
+	switch obj := obj.(type) {
+	case Nil, Boolean, Int, Double, *BigInt, *BigFloat, *Ratio, String, Char, Keyword, Symbol, *Regex:
+		return true
+	case Map:
+		for iter := obj.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			if !isReadable(p.Key) || !isReadable(p.Value) {
+				return false
+			}
+		}
+		return true
+	case Vec, Set, Seq:
+		for s := obj.(Seqable).Seq(); !s.IsEmpty(); s = s.Rest() {
+			if !isReadable(s.First()) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 func unpackObject(p []byte, header *PackHeader) (Object, []byte) {
 	switch p[0] {
 	case SYMBOL_OBJ:
@@ -615,7 +650,10 @@ func (vr *Var) Pack(p []byte, env *PackEnv) []byte {
 func unpackVar(p []byte, header *PackHeader) (*Var, []byte) {
 	nsName, p := unpackSymbol(p, header)
 	name, p := unpackSymbol(p, header)
-	vr := GLOBAL_ENV.FindNamespace(nsName).mappings[name.name]
+	var vr *Var
+	if ns := GLOBAL_ENV.FindNamespace(nsName); ns != nil {
+		vr = ns.mappings[name.name]
+	}
 	if vr == nil {
 		panic(RT.NewError("Error unpacking var: cannot find var " + *nsName.name + "/" + *name.name))
 	}
@@ -87,6 +87,7 @@ type (
 		args       []Symbol
 		body       []Expr
 		taggedType *Type
+		compiled   compiledExpr
 	}
 	FnExpr struct {
 		Position
@@ -153,6 +154,8 @@ type (
 		recur                  bool
 		noRecurAllowed         bool
 		isUnknownCallableScope bool
+		// If not nil, macros expanded by the parser are added to it.
+		macros map[*Var]bool
 	}
 	Warnings struct {
 		ifWithoutElse           bool
@@ -179,6 +182,9 @@ type (
 		doc                Keyword
 		added              Keyword
 		meta               Keyword
+		validator          Keyword
+		_default           Keyword
+		priority           Keyword
 		knownMacros        Keyword
 		rules              Keyword
 		ifWithoutElse      Keyword
@@ -247,6 +253,8 @@ type (
 		hashMap            Symbol
 		hashSet            Symbol
 		defaultDataReaders Symbol
+		dataReaders        Symbol
+		defaultReaderFn    Symbol
 		backslash          Symbol
 		deref              Symbol
 		ns                 Symbol
@@ -373,7 +381,7 @@ func (b *Bindings) AddBinding(sym Symbol, index int, skipUnused bool, inferredTy
 	if LINTER_MODE && !skipUnused {
 		old := b.bindings[sym.name]
 		if old != nil && needsUnusedWarning(old) {
-			printParseWarning(GetPosition(old.name), "Unused binding: "+old.name.ToString(false))
+			printParseWarning(GetPosition(old.name), "unused-binding", "Unused binding: "+old.name.ToString(false))
 		}
 	}
 	b.bindings[sym.name] = &Binding{
@@ -421,35 +429,34 @@ func (pos Position) Pos() Position {
 	return pos
 }
 
-func printError(pos Position, msg string) {
-	PROBLEM_COUNT++
-	fmt.Fprintf(Stderr, "%s:%d:%d: %s\n", pos.Filename(), pos.startLine, pos.startColumn, msg)
+func printError(pos Position, phase string, rule string, severity LintSeverity, msg string) {
+	reportLintProblem(LintProblem{pos: pos, phase: phase, rule: rule, severity: severity, msg: msg})
 }
 
-func printParseWarning(pos Position, msg string) {
-	printError(pos, "Parse warning: "+msg)
+func printParseWarning(pos Position, rule string, msg string) {
+	printError(pos, "Parse", rule, LINT_WARNING, msg)
 }
 
-func printParseError(pos Position, msg string) {
-	printError(pos, "Parse error: "+msg)
+func printParseError(pos Position, rule string, msg string) {
+	printError(pos, "Parse", rule, LINT_ERROR, msg)
 }
 
-func printReadWarning(reader *Reader, msg string) {
+func printReadWarning(reader *Reader, rule string, msg string) {
 	pos := Position{
 		filename:    reader.filename,
 		startColumn: reader.column,
 		startLine:   reader.line,
 	}
-	printError(pos, "Read warning: "+msg)
+	printError(pos, "Read", rule, LINT_WARNING, msg)
 }
 
-func printReadError(reader *Reader, msg string) {
+func printReadError(reader *Reader, rule string, msg string) {
 	pos := Position{
 		filename:    reader.filename,
 		startColumn: reader.column,
 		startLine:   reader.line,
 	}
-	printError(pos, "Read error: "+msg)
+	printError(pos, "Read", rule, LINT_ERROR, msg)
 }
 
 func isIgnoredUnusedNamespace(ns *Namespace) bool {
@@ -477,24 +484,44 @@ func isEntryPointNs(ns *Namespace) bool {
 	return ok
 }
 
-func WarnOnGloballyUnusedNamespaces() {
-	var names []string
-	positions := make(map[string]Position)
-
+// collectLintUsage returns the namespaces and public vars defined
+// so far that aren't globally used, and the names of those that are.
+func collectLintUsage() *lintUsage {
+	res := newLintUsage()
 	for _, ns := range GLOBAL_ENV.Namespaces {
-		if !ns.isGloballyUsed && !isIgnoredUnusedNamespace(ns) && !isEntryPointNs(ns) {
+		name := ns.Name.ToString(false)
+		if ns.isGloballyUsed {
+			res.UsedNamespaces = append(res.UsedNamespaces, name)
+		} else if !isIgnoredUnusedNamespace(ns) && !isEntryPointNs(ns) {
 			pos := ns.Name.GetInfo()
 			if pos != nil && pos.Filename() != "<joker.core>" && pos.Filename() != "<user>" {
-				name := ns.Name.ToString(false)
-				names = append(names, name)
-				positions[name] = pos.Position
+				res.Namespaces[name] = makeLintPosition(pos.Position)
+			}
+		}
+		if ns == GLOBAL_ENV.CoreNamespace {
+			continue
+		}
+		for _, vr := range ns.mappings {
+			if vr.ns != ns {
+				continue
+			}
+			if vr.isGloballyUsed {
+				res.UsedVars = append(res.UsedVars, vr.Name())
+			} else if !vr.isPrivate && !isRecordConstructor(vr.name) && !isEntryPointVar(vr) {
+				pos := vr.GetInfo()
+				if pos != nil {
+					res.Vars[vr.Name()] = makeLintPosition(pos.Position)
+				}
 			}
 		}
 	}
+	return res
+}
 
-	sort.Strings(names)
-	for _, name := range names {
-		printParseWarning(positions[name], "globally unused namespace "+name)
+func WarnOnGloballyUnusedNamespaces() {
+	positions := lintGlobalUsage.unused(lintGlobalUsage.Namespaces, lintGlobalUsage.UsedNamespaces)
+	for _, name := range sortedKeys(positions) {
+		printParseWarning(positions[name].position(), "globally-unused-namespace", "globally unused namespace "+name)
 	}
 }
 
@@ -515,7 +542,7 @@ func WarnOnUnusedNamespaces() {
 
 	sort.Strings(names)
 	for _, name := range names {
-		printParseWarning(positions[name], "unused namespace "+name)
+		printParseWarning(positions[name], "unused-namespace", "unused namespace "+name)
 	}
 }
 
@@ -532,28 +559,9 @@ func isEntryPointVar(vr *Var) bool {
 }
 
 func WarnOnGloballyUnusedVars() {
-	var names []string
-	positions := make(map[string]Position)
-
-	for _, ns := range GLOBAL_ENV.Namespaces {
-		if ns == GLOBAL_ENV.CoreNamespace {
-			continue
-		}
-		for _, vr := range ns.mappings {
-			if vr.ns == ns && !vr.isGloballyUsed && !vr.isPrivate && !isRecordConstructor(vr.name) && !isEntryPointVar(vr) {
-				pos := vr.GetInfo()
-				if pos != nil {
-					varName := vr.Name()
-					names = append(names, varName)
-					positions[varName] = pos.Position
-				}
-			}
-		}
-	}
-
-	sort.Strings(names)
-	for _, name := range names {
-		printParseWarning(positions[name], "globally unused var "+name)
+	positions := lintGlobalUsage.unused(lintGlobalUsage.Vars, lintGlobalUsage.UsedVars)
+	for _, name := range sortedKeys(positions) {
+		printParseWarning(positions[name].position(), "globally-unused-var", "globally unused var "+name)
 	}
 }
 
@@ -578,7 +586,7 @@ func WarnOnUnusedVars() {
 
 	sort.Strings(names)
 	for _, name := range names {
-		printParseWarning(positions[name], "unused var "+name)
+		printParseWarning(positions[name], "unused-var", "unused var "+name)
 	}
 }
 
@@ -762,6 +770,9 @@ func parseDef(obj Object, ctx *ParseContext, isForLinter bool) *DefExpr {
 			}
 		}
 		updateVar(vr, obj.GetInfo(), res.value, sym)
+		if LINTER_MODE {
+			noteLintDef(vr)
+		}
 		if meta != nil {
 			res.meta = Parse(DeriveReadObject(obj, meta), ctx)
 		}
@@ -797,9 +808,9 @@ func parseBody(seq Seq, ctx *ParseContext) []Expr {
 		res = append(res, expr)
 		if LINTER_MODE {
 			if defExpr, ok := expr.(*DefExpr); ok && !defExpr.isCreatedByMacro {
-				printParseWarning(defExpr.Pos(), "inline def")
+				printParseWarning(defExpr.Pos(), "inline-def", "inline def")
 			} else if doExpr, ok := expr.(*DoExpr); ok && !doExpr.isCreatedByMacro && !skipRedundantDo(ro) {
-				printParseWarning(doExpr.Pos(), "redundant do form")
+				printParseWarning(doExpr.Pos(), "redundant-do", "redundant do form")
 			}
 		}
 	}
@@ -896,7 +907,7 @@ func addArity(fn *FnExpr, sig Seq, ctx *ParseContext) {
 	if LINTER_MODE {
 		if WARNINGS.fnWithEmptyBody {
 			if len(arity.body) == 0 {
-				printParseWarning(arity.Position, "fn form with empty body")
+				printParseWarning(arity.Position, "fn-with-empty-body", "fn form with empty body")
 			}
 		}
 
@@ -909,7 +920,7 @@ func addArity(fn *FnExpr, sig Seq, ctx *ParseContext) {
 			}
 			sort.Sort(BySymbolName(unused))
 			for _, u := range unused {
-				printParseWarning(GetPosition(u), "unused parameter: "+u.ToString(false))
+				printParseWarning(GetPosition(u), "unused-fn-parameters", "unused parameter: "+u.ToString(false))
 			}
 		}
 	}
@@ -1051,13 +1062,13 @@ func parseTry(obj Object, ctx *ParseContext) *TryExpr {
 	}
 	if LINTER_MODE {
 		if res.body == nil {
-			printParseWarning(res.Pos(), "try form with empty body")
+			printParseWarning(res.Pos(), "empty-body", "try form with empty body")
 		}
 		if res.catches == nil && res.finallyExpr == nil {
-			printParseWarning(res.Pos(), "try form without catch or finally")
+			printParseWarning(res.Pos(), "try-without-catch", "try form without catch or finally")
 		}
 		if res.finallyExpr != nil && len(res.finallyExpr) == 0 {
-			printParseWarning(GetPosition(obj), "finally form with empty body")
+			printParseWarning(GetPosition(obj), "empty-body", "finally form with empty body")
 		}
 	}
 	return res
@@ -1097,7 +1108,7 @@ func parseLetLoop(obj Object, formName string, ctx *ParseContext) *LetExpr {
 		}
 		if LINTER_MODE && formName != "loop" && cnt == 0 {
 			pos := GetPosition(obj)
-			printParseWarning(pos, formName+" form with empty bindings vector")
+			printParseWarning(pos, "empty-bindings", formName+" form with empty bindings vector")
 		}
 		skipUnused := isSkipUnused(b)
 		res.names = make([]Symbol, cnt/2)
@@ -1112,7 +1123,7 @@ func parseLetLoop(obj Object, formName string, ctx *ParseContext) *LetExpr {
 				if sym.ns != nil {
 					msg := "Can't let qualified name: " + sym.ToString(false)
 					if LINTER_MODE {
-						printParseError(GetPosition(s), msg)
+						printParseError(GetPosition(s), "qualified-binding", msg)
 					} else {
 						panic(&ParseError{obj: s, msg: msg})
 					}
@@ -1155,7 +1166,7 @@ func parseLetLoop(obj Object, formName string, ctx *ParseContext) *LetExpr {
 		if LINTER_MODE {
 			if len(res.body) == 0 {
 				pos := GetPosition(obj)
-				printParseWarning(pos, formName+" form with empty body")
+				printParseWarning(pos, "empty-body", formName+" form with empty body")
 			}
 
 			if !skipUnused {
@@ -1167,7 +1178,7 @@ func parseLetLoop(obj Object, formName string, ctx *ParseContext) *LetExpr {
 				}
 				sort.Sort(BySymbolName(unused))
 				for _, u := range unused {
-					printParseWarning(GetPosition(u), "unused binding: "+u.ToString(false))
+					printParseWarning(GetPosition(u), "unused-binding", "unused binding: "+u.ToString(false))
 				}
 			}
 		}
@@ -1208,6 +1219,7 @@ func resolveMacro(obj Object, ctx *ParseContext) *Var {
 		if !ok || !vr.isMacro || vr.Value == nil {
 			return nil
 		}
+		checkSandbox(vr, obj)
 		vr.isUsed = true
 		vr.isGloballyUsed = true
 		if vr.ns == nil {
@@ -1222,6 +1234,9 @@ func resolveMacro(obj Object, ctx *ParseContext) *Var {
 		}
 		vr.ns.isUsed = true
 		vr.ns.isGloballyUsed = true
+		if ctx.macros != nil {
+			ctx.macros[vr] = true
+		}
 		return vr
 	default:
 		return nil
@@ -1294,7 +1309,7 @@ func macroexpand1(seq Seq, ctx *ParseContext) Object {
 }
 
 func reportNotAFunction(pos Position, name string) {
-	printParseWarning(pos, name+" is not a function")
+	printParseWarning(pos, "not-a-function", name+" is not a function")
 }
 
 func getTaggedType(obj Meta) *Type {
@@ -1359,7 +1374,7 @@ func checkTypes(declaredArgs []Symbol, call *CallExpr) bool {
 			passedType := call.args[i].InferType()
 			if passedType != nil {
 				if !isTypeOneOf(declaredTypes, passedType) {
-					printParseWarning(call.args[i].Pos(), fmt.Sprintf("arg[%d] of %s must have type %s, got %s", i, call.Name(), typesString(declaredTypes), passedType.ToString(false)))
+					printParseWarning(call.args[i].Pos(), "arg-type", fmt.Sprintf("arg[%d] of %s must have type %s, got %s", i, call.Name(), typesString(declaredTypes), passedType.ToString(false)))
 					res = true
 				}
 			}
@@ -1388,7 +1403,7 @@ func reportWrongArity(expr *FnExpr, isMacro bool, call *CallExpr, pos Position)
 	if v := selectArity(expr, passedArgsCount); v != nil {
 		return checkTypes(v.args, call)
 	}
-	printParseWarning(pos, fmt.Sprintf("Wrong number of args (%d) passed to %s", len(call.args), call.Name()))
+	printParseWarning(pos, "wrong-arity", fmt.Sprintf("Wrong number of args (%d) passed to %s", len(call.args), call.Name()))
 	return true
 }
 
@@ -1530,11 +1545,11 @@ func checkCall(expr Expr, isMacro bool, call *CallExpr, pos Position) {
 		reportWrongArity(expr, isMacro, call, pos)
 	case *MapExpr:
 		if argsCount == 0 || argsCount > 2 {
-			printParseWarning(pos, fmt.Sprintf("Wrong number of args (%d) passed to a map", argsCount))
+			printParseWarning(pos, "wrong-arity", fmt.Sprintf("Wrong number of args (%d) passed to a map", argsCount))
 		}
 	case *SetExpr:
 		if argsCount == 0 || argsCount > 1 {
-			printParseWarning(pos, fmt.Sprintf("Wrong number of args (%d) passed to a set", argsCount))
+			printParseWarning(pos, "wrong-arity", fmt.Sprintf("Wrong number of args (%d) passed to a set", argsCount))
 		}
 	case *LiteralExpr:
 		if _, ok := expr.obj.(Callable); !ok && !expr.isSurrogate {
@@ -1544,7 +1559,7 @@ func checkCall(expr Expr, isMacro bool, call *CallExpr, pos Position) {
 		switch expr.obj.(type) {
 		case Keyword:
 			if argsCount == 0 || argsCount > 2 {
-				printParseWarning(pos, fmt.Sprintf("Wrong number of args (%d) passed to %s", argsCount, call.Name()))
+				printParseWarning(pos, "wrong-arity", fmt.Sprintf("Wrong number of args (%d) passed to %s", argsCount, call.Name()))
 			}
 		}
 	case *RecurExpr:
@@ -1580,7 +1595,7 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 		case STR._if:
 			checkForm(obj, 3, 4)
 			if LINTER_MODE && SeqCount(seq) < 4 && WARNINGS.ifWithoutElse {
-				printParseWarning(pos, "missing else branch")
+				printParseWarning(pos, "if-without-else", "missing else branch")
 			}
 			return &IfExpr{
 				cond:     Parse(Second(seq), ctx),
@@ -1619,12 +1634,13 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 					}
 					symNs := ctx.GlobalEnv.NamespaceFor(ctx.GlobalEnv.CurrentNamespace(), sym)
 					if !ctx.isUnknownCallableScope {
-						if symNs == nil || symNs == ctx.GlobalEnv.CurrentNamespace() {
-							printParseError(GetPosition(obj), "Unable to resolve symbol: "+sym.ToString(false))
+						if symNs == nil || symNs == ctx.GlobalEnv.CurrentNamespace() || lintUnresolvedNamespaces[symNs] {
+							printParseError(GetPosition(obj), "unresolved-symbol", "Unable to resolve symbol: "+sym.ToString(false))
 						}
 					}
 					vr = InternFakeSymbol(symNs, sym)
 				}
+				checkSandbox(vr, obj)
 				vr.isUsed = true
 				vr.isGloballyUsed = true
 				vr.ns.isUsed = true
@@ -1644,9 +1660,9 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 			}
 			if LINTER_MODE {
 				if len(res.body) == 0 {
-					printParseWarning(pos, "do form with empty body")
+					printParseWarning(pos, "empty-body", "do form with empty body")
 				} else if len(res.body) == 1 {
-					printParseWarning(pos, "redundant do form")
+					printParseWarning(pos, "redundant-do", "redundant do form")
 				}
 			}
 			return res
@@ -1711,7 +1727,7 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 						if ok, arglist := m.Get(KEYWORDS.arglist); ok {
 							if arglist, ok := arglist.(Seq); ok {
 								if !checkArglist(arglist, len(res.args)) {
-									printParseWarning(pos, fmt.Sprintf("Wrong number of args (%d) passed to %s", len(res.args), res.Name()))
+									printParseWarning(pos, "wrong-arity", fmt.Sprintf("Wrong number of args (%d) passed to %s", len(res.args), res.Name()))
 								}
 							}
 						}
@@ -1721,6 +1737,9 @@ func parseList(obj Object, ctx *ParseContext) Expr {
 					reportNotAFunction(pos, res.Name())
 				}
 			} else {
+				if c.vr.expr == nil {
+					noteLintReference(c.vr, pos, len(res.args))
+				}
 				checkCall(c.vr.expr, c.vr.isMacro, res, pos)
 			}
 		default:
@@ -1785,6 +1804,10 @@ func parseSymbol(obj Object, ctx *ParseContext) Expr {
 		}
 	}
 	if vr, ok := ctx.GlobalEnv.Resolve(sym); ok {
+		checkSandbox(vr, obj)
+		if LINTER_MODE && sym.ns != nil {
+			noteLintSymbolReference(vr, obj, ctx)
+		}
 		return MakeVarRefExpr(vr, obj)
 	}
 	if sym.ns == nil && TYPES[sym.name] != nil {
@@ -1820,17 +1843,38 @@ func parseSymbol(obj Object, ctx *ParseContext) Expr {
 		}
 	}
 	symNs := ctx.GlobalEnv.NamespaceFor(ctx.GlobalEnv.CurrentNamespace(), sym)
+	if symNs == nil && !isInteropSymbol(sym) && !isJavaSymbol(sym) {
+		symNs = lintUnresolvedNamespace(sym)
+	}
 	if symNs == nil || symNs == ctx.GlobalEnv.CurrentNamespace() {
 		if isInteropSymbol(sym) || isJavaSymbol(sym) {
 			return NewSurrogateExpr(sym)
 		}
 		if !ctx.isUnknownCallableScope {
 			if ctx.linterBindings.GetBinding(sym) == nil {
-				printParseError(GetPosition(obj), "Unable to resolve symbol: "+sym.ToString(false))
+				printParseError(GetPosition(obj), "unresolved-symbol", "Unable to resolve symbol: "+sym.ToString(false))
 			}
 		}
 	}
-	return MakeVarRefExpr(InternFakeSymbol(symNs, sym), obj)
+	vr := InternFakeSymbol(symNs, sym)
+	if sym.ns == nil {
+		// Possibly defined by an unknown macro.
+		noteLintDef(vr)
+	} else {
+		noteLintSymbolReference(vr, obj, ctx)
+	}
+	return MakeVarRefExpr(vr, obj)
+}
+
+// noteLintSymbolReference records a reference to vr by the qualified
+// symbol obj. If the symbol's namespace didn't exist, the reference
+// is an error unless another file of the project defines it.
+func noteLintSymbolReference(vr *Var, obj Object, ctx *ParseContext) {
+	pos := GetPosition(obj)
+	if !ctx.isUnknownCallableScope && noteUnresolvedLintReference(vr, pos) {
+		return
+	}
+	noteLintReference(vr, pos, -1)
 }
 
 func Parse(obj Object, ctx *ParseContext) Expr {
@@ -1878,6 +1922,10 @@ func TryParse(obj Object, ctx *ParseContext) (expr Expr, err error) {
 				err = r.(error)
 			case *EvalError:
 				err = r.(error)
+			case *StackOverflow:
+				err = r.(error)
+			case *LimitExceeded:
+				err = r.(error)
 			case *ExInfo:
 				err = r.(error)
 			default:
@@ -22,6 +22,9 @@ var (
 		doc:                MakeKeyword("doc"),
 		added:              MakeKeyword("added"),
 		meta:               MakeKeyword("meta"),
+		validator:          MakeKeyword("validator"),
+		_default:           MakeKeyword("default"),
+		priority:           MakeKeyword("priority"),
 		knownMacros:        MakeKeyword("known-macros"),
 		rules:              MakeKeyword("rules"),
 		ifWithoutElse:      MakeKeyword("if-without-else"),
@@ -90,6 +93,8 @@ var (
 		hashMap:            MakeSymbol("hash-map"),
 		hashSet:            MakeSymbol("hash-set"),
 		defaultDataReaders: MakeSymbol("default-data-readers"),
+		dataReaders:        MakeSymbol("*data-readers*"),
+		defaultReaderFn:    MakeSymbol("*default-data-reader-fn*"),
 		backslash:          MakeSymbol("/"),
 		deref:              MakeSymbol("deref"),
 		ns:                 MakeSymbol("ns"),
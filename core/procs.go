@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -446,9 +447,10 @@ var procSubs = func(args []Object) Object {
 var procIntern = func(args []Object) Object {
 	ns := EnsureArgIsNamespace(args, 0)
 	sym := EnsureArgIsSymbol(args, 1)
+	RT.checkNamespaceOwner(ns)
 	vr := ns.Intern(sym)
 	if len(args) == 3 {
-		vr.Value = args[2]
+		RT.setVarRoot(vr, args[2])
 	}
 	return vr
 }
@@ -456,6 +458,7 @@ var procIntern = func(args []Object) Object {
 var procSetMeta = func(args []Object) Object {
 	vr := EnsureArgIsVar(args, 0)
 	meta := EnsureArgIsMap(args, 1)
+	RT.checkNamespaceOwner(vr.ns)
 	vr.meta = meta
 	return NIL
 }
@@ -469,53 +472,127 @@ var procAtom = func(args []Object) Object {
 		if ok, v := m.Get(KEYWORDS.meta); ok {
 			res.meta = EnsureObjectIsMap(v, "")
 		}
+		if ok, v := m.Get(KEYWORDS.validator); ok && !v.Equals(NIL) {
+			EnsureObjectIsCallable(v, ":validator must be a function, got %s")
+			res.validator = v
+			res.validate(res.value)
+		}
 	}
 	return res
 }
 
 var procDeref = func(args []Object) Object {
+	if len(args) == 3 {
+		ms := EnsureArgIsInt(args, 1).I
+		switch ref := args[0].(type) {
+		case BlockingDeref:
+			return ref.DerefWithTimeout(time.Duration(ms)*time.Millisecond, args[2])
+		default:
+			panic(RT.NewArgTypeError(0, args[0], "Future or Promise"))
+		}
+	}
 	return EnsureArgIsDeref(args, 0).Deref()
 }
 
 var procSwap = func(args []Object) Object {
 	a := EnsureArgIsAtom(args, 0)
 	f := EnsureArgIsCallable(args, 1)
-	fargs := append([]Object{a.value}, args[2:]...)
-	a.value = f.Call(fargs)
-	return a.value
+	for {
+		version := a.version
+		newValue := f.Call(append([]Object{a.value}, args[2:]...))
+		if a.compareAndSet(version, newValue) {
+			return newValue
+		}
+	}
 }
 
 var procSwapVals = func(args []Object) Object {
 	a := EnsureArgIsAtom(args, 0)
 	f := EnsureArgIsCallable(args, 1)
-	fargs := append([]Object{a.value}, args[2:]...)
-	oldValue := a.value
-	a.value = f.Call(fargs)
-	return NewVectorFrom(oldValue, a.value)
+	for {
+		version, oldValue := a.version, a.value
+		newValue := f.Call(append([]Object{oldValue}, args[2:]...))
+		if a.compareAndSet(version, newValue) {
+			return NewVectorFrom(oldValue, newValue)
+		}
+	}
 }
 
 var procReset = func(args []Object) Object {
 	a := EnsureArgIsAtom(args, 0)
-	a.value = args[1]
-	return a.value
+	a.set(args[1])
+	return args[1]
 }
 
 var procResetVals = func(args []Object) Object {
 	a := EnsureArgIsAtom(args, 0)
 	oldValue := a.value
-	a.value = args[1]
-	return NewVectorFrom(oldValue, a.value)
+	a.set(args[1])
+	return NewVectorFrom(oldValue, args[1])
+}
+
+var procAddWatch = func(args []Object) Object {
+	a := EnsureArgIsAtom(args, 0)
+	EnsureArgIsCallable(args, 2)
+	if a.watches == nil {
+		a.watches = EmptyArrayMap()
+	}
+	a.watches = a.watches.Assoc(args[1], args[2]).(Map)
+	return a
+}
+
+var procRemoveWatch = func(args []Object) Object {
+	a := EnsureArgIsAtom(args, 0)
+	if a.watches != nil {
+		a.watches = a.watches.Without(args[1])
+	}
+	return a
+}
+
+var procSetValidator = func(args []Object) Object {
+	a := EnsureArgIsAtom(args, 0)
+	if args[1].Equals(NIL) {
+		a.validator = nil
+		return NIL
+	}
+	validator := EnsureArgIsCallable(args, 1)
+	if !ToBool(validator.Call([]Object{a.value})) {
+		panic(RT.NewError("Invalid reference state"))
+	}
+	a.validator = args[1]
+	return NIL
+}
+
+var procGetValidator = func(args []Object) Object {
+	a := EnsureArgIsAtom(args, 0)
+	if a.validator == nil {
+		return NIL
+	}
+	return a.validator
+}
+
+// checkRefOwner panics if r is a var or a namespace
+// that the current runtime can't change (see Isolate).
+func checkRefOwner(r Ref) {
+	switch r := r.(type) {
+	case *Var:
+		RT.checkNamespaceOwner(r.ns)
+	case *Namespace:
+		RT.checkNamespaceOwner(r)
+	}
 }
 
 var procAlterMeta = func(args []Object) Object {
 	r := EnsureArgIsRef(args, 0)
 	f := EnsureArgIsFn(args, 1)
+	checkRefOwner(r)
 	return r.AlterMeta(f, args[2:])
 }
 
 var procResetMeta = func(args []Object) Object {
 	r := EnsureArgIsRef(args, 0)
 	m := EnsureArgIsMap(args, 1)
+	checkRefOwner(r)
 	return r.ResetMeta(m)
 }
 
@@ -707,7 +784,7 @@ func str(args ...Object) string {
 		if !obj.Equals(NIL) {
 			t := obj.GetType()
 			// TODO: this is a hack. Rethink escape parameter in ToString
-			escaped := (t == TYPE.String) || (t == TYPE.Char) || (t == TYPE.Regex)
+			escaped := (t == TYPE.String) || (t == TYPE.Char) || (t == TYPE.Regex) || (t == TYPE.UUID)
 			buffer.WriteString(obj.ToString(!escaped))
 		}
 	}
@@ -715,7 +792,7 @@ func str(args ...Object) string {
 }
 
 var procStr = func(args []Object) Object {
-	return String{S: str(args...)}
+	return MakeString(str(args...))
 }
 
 var procSymbol = func(args []Object) Object {
@@ -797,19 +874,22 @@ var procIdentical = func(args []Object) Object {
 }
 
 var procCompare = func(args []Object) Object {
-	k1, k2 := args[0], args[1]
+	return Int{I: compareObjects(args[0], args[1])}
+}
+
+func compareObjects(k1, k2 Object) int {
 	if k1.Equals(k2) {
-		return Int{I: 0}
+		return 0
 	}
 	switch k2.(type) {
 	case Nil:
-		return Int{I: 1}
+		return 1
 	}
 	switch k1 := k1.(type) {
 	case Nil:
-		return Int{I: -1}
+		return -1
 	case Comparable:
-		return Int{I: k1.Compare(k2)}
+		return k1.Compare(k2)
 	}
 	panic(RT.NewError(fmt.Sprintf("%s (type: %s) is not a Comparable", k1.ToString(true), k1.GetType().ToString(false))))
 }
@@ -1042,7 +1122,11 @@ var procVals = func(args []Object) Object {
 }
 
 var procRseq = func(args []Object) Object {
-	return EnsureArgIsReversible(args, 0).Rseq()
+	s := EnsureArgIsReversible(args, 0).Rseq()
+	if s.IsEmpty() {
+		return NIL
+	}
+	return s
 }
 
 var procName = func(args []Object) Object {
@@ -1082,7 +1166,7 @@ var procSort = func(args []Object) Object {
 var procEval = func(args []Object) Object {
 	parseContext := &ParseContext{GlobalEnv: GLOBAL_ENV}
 	expr := Parse(args[0], parseContext)
-	return Eval(expr, nil)
+	return evalTopLevel(expr)
 }
 
 var procType = func(args []Object) Object {
@@ -1251,7 +1335,11 @@ var procFindNamespace = func(args []Object) Object {
 
 var procCreateNamespace = func(args []Object) Object {
 	sym := EnsureArgIsSymbol(args, 0)
+	isNew := GLOBAL_ENV.Namespaces[sym.name] == nil
 	res := GLOBAL_ENV.EnsureSymbolIsNamespace(sym)
+	if isNew {
+		RT.OwnNamespace(res)
+	}
 	// In linter mode the latest create-ns call overrides position info.
 	// This is for the cases when (ns ...) is called in .jokerd/linter.clj file and alike.
 	// Also, isUsed needs to be reset in this case.
@@ -1277,7 +1365,9 @@ var procInjectLinterType = func(args []Object) Object {
 }
 
 var procRemoveNamespace = func(args []Object) Object {
-	ns := GLOBAL_ENV.RemoveNamespace(EnsureArgIsSymbol(args, 0))
+	sym := EnsureArgIsSymbol(args, 0)
+	RT.checkNamespaceOwner(GLOBAL_ENV.FindNamespace(sym))
+	ns := GLOBAL_ENV.RemoveNamespace(sym)
 	if ns == nil {
 		return NIL
 	}
@@ -1310,6 +1400,7 @@ var procNamespaceUnmap = func(args []Object) Object {
 	if sym.ns != nil {
 		panic(RT.NewError("Can't unintern namespace-qualified symbol"))
 	}
+	RT.checkNamespaceOwner(ns)
 	delete(ns.mappings, sym.name)
 	return NIL
 }
@@ -1323,11 +1414,14 @@ var procRefer = func(args []Object) Object {
 	ns := EnsureArgIsNamespace(args, 0)
 	sym := EnsureArgIsSymbol(args, 1)
 	v := EnsureArgIsVar(args, 2)
+	RT.checkNamespaceOwner(ns)
 	return ns.Refer(sym, v)
 }
 
 var procAlias = func(args []Object) Object {
-	EnsureArgIsNamespace(args, 0).AddAlias(EnsureArgIsSymbol(args, 1), EnsureArgIsNamespace(args, 2))
+	ns := EnsureArgIsNamespace(args, 0)
+	RT.checkNamespaceOwner(ns)
+	ns.AddAlias(EnsureArgIsSymbol(args, 1), EnsureArgIsNamespace(args, 2))
 	return NIL
 }
 
@@ -1345,6 +1439,7 @@ var procNamespaceUnalias = func(args []Object) Object {
 	if sym.ns != nil {
 		panic(RT.NewError("Alias can't be namespace-qualified"))
 	}
+	RT.checkNamespaceOwner(ns)
 	delete(ns.aliases, sym.name)
 	return NIL
 }
@@ -1354,10 +1449,36 @@ var procVarGet = func(args []Object) Object {
 }
 
 var procVarSet = func(args []Object) Object {
-	EnsureArgIsVar(args, 0).Value = args[1]
+	vr := EnsureArgIsVar(args, 0)
+	// ns and require add the libs they load to *loaded-libs*,
+	// which is shared by interpreters.
+	if _, ok := RT.bound[vr]; !ok && vr != GLOBAL_ENV.libs {
+		RT.checkNamespaceOwner(vr.ns)
+	}
+	if ns, ok := args[1].(*Namespace); ok && vr == GLOBAL_ENV.ns {
+		RT.checkNamespaceOwner(ns)
+	}
+	vr.Value = args[1]
 	return args[1]
 }
 
+var procPushThreadBindings = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	RT.pushThreadBindings(EnsureArgIsMap(args, 0))
+	return NIL
+}
+
+var procPopThreadBindings = func(args []Object) Object {
+	CheckArity(args, 0, 0)
+	RT.popThreadBindings()
+	return NIL
+}
+
+var procGetThreadBindings = func(args []Object) Object {
+	CheckArity(args, 0, 0)
+	return RT.threadBindings()
+}
+
 var procNsResolve = func(args []Object) Object {
 	ns := EnsureArgIsNamespace(args, 0)
 	sym := EnsureArgIsSymbol(args, 1)
@@ -1403,7 +1524,10 @@ var procBufferedReader = func(args []Object) Object {
 var procSlurp = func(args []Object) Object {
 	switch f := args[0].(type) {
 	case String:
-		b, err := ioutil.ReadFile(f.S)
+		checkSandboxPath(f.S)
+		var b []byte
+		var err error
+		RT.RunBlocking(func() { b, err = ioutil.ReadFile(f.S) }, nil)
 		PanicOnErr(err)
 		return String{S: string(b)}
 	case io.Reader:
@@ -1431,10 +1555,16 @@ var procSpit = func(args []Object) Object {
 	}
 	switch f := f.(type) {
 	case String:
-		file, err := os.OpenFile(f.S, flags, 0644)
-		PanicOnErr(err)
-		defer file.Close()
-		_, err = file.WriteString(str(content))
+		checkSandboxPath(f.S)
+		s := str(content)
+		var err error
+		RT.RunBlocking(func() {
+			var file *os.File
+			if file, err = os.OpenFile(f.S, flags, 0644); err == nil {
+				_, err = file.WriteString(s)
+				file.Close()
+			}
+		}, nil)
 		PanicOnErr(err)
 	case io.Writer:
 		_, err := io.WriteString(f, str(content))
@@ -1484,6 +1614,7 @@ func loadFile(filename string) Object {
 
 var procLoadFile = func(args []Object) Object {
 	filename := EnsureArgIsString(args, 0)
+	checkSandboxPath(filename.S)
 	return loadFile(filename.S)
 }
 
@@ -1506,7 +1637,10 @@ var procLoadLibFromPath = func(args []Object) Object {
 		} else {
 			filename = filepath.Join(s, filepath.Join(strings.Split(libname, ".")...)) + ".joke" // could cache inner join....
 		}
-		f, err = os.Open(filename)
+		// Libs outside of the sandbox's paths are treated as missing.
+		if err = sandboxPathError(filename); err == nil {
+			f, err = os.Open(filename)
+		}
 		if err == nil {
 			canonicalErr = nil
 			break
@@ -1517,8 +1651,8 @@ var procLoadLibFromPath = func(args []Object) Object {
 	}
 	PanicOnErr(canonicalErr)
 	PanicOnErr(err)
-	reader := NewReader(bufio.NewReader(f), filename)
-	ProcessReaderFromEval(reader, filename)
+	defer f.Close()
+	RT.shareNewNamespaces(func() { loadLib(f, filename) })
 	return NIL
 }
 
@@ -1540,6 +1674,21 @@ var procReduce = func(args []Object) Object {
 	return coll.reduceInit(f, init)
 }
 
+var procReduced = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return &Reduced{value: args[0]}
+}
+
+var procReadInstant = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return readInstant(EnsureArgIsString(args, 0).S)
+}
+
+var procReadUUID = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return readUUID(EnsureArgIsString(args, 0).S)
+}
+
 var procIndexOf = func(args []Object) Object {
 	s := EnsureArgIsString(args, 0)
 	ch := EnsureArgIsChar(args, 1)
@@ -1576,6 +1725,34 @@ func libExternalPath(sym Symbol) (path string, ok bool) {
 	return
 }
 
+// namespaceRoot returns the directory that the current namespace
+// is loaded relative to, i.e. the directory of the current file
+// with one level stripped per segment of the namespace name.
+func namespaceRoot() string {
+	var file string
+	if GLOBAL_ENV.file.Value == nil {
+		var err error
+		file, err = filepath.Abs("user")
+		PanicOnErr(err)
+	} else {
+		file = EnsureObjectIsString(GLOBAL_ENV.file.Value, "").S
+		if linkDest, err := os.Readlink(file); err == nil {
+			file = linkDest
+		}
+	}
+	ns := GLOBAL_ENV.CurrentNamespace().Name
+
+	parts := strings.Split(ns.Name(), ".")
+	for _ = range parts {
+		file, _ = filepath.Split(file)
+		if len(file) == 0 {
+			break
+		}
+		file = file[:len(file)-1]
+	}
+	return file
+}
+
 var procLibPath = func(args []Object) Object {
 	sym := EnsureArgIsSymbol(args, 0)
 	var path string
@@ -1583,28 +1760,7 @@ var procLibPath = func(args []Object) Object {
 	path, ok := libExternalPath(sym)
 
 	if !ok {
-		var file string
-		if GLOBAL_ENV.file.Value == nil {
-			var err error
-			file, err = filepath.Abs("user")
-			PanicOnErr(err)
-		} else {
-			file = EnsureObjectIsString(GLOBAL_ENV.file.Value, "").S
-			if linkDest, err := os.Readlink(file); err == nil {
-				file = linkDest
-			}
-		}
-		ns := GLOBAL_ENV.CurrentNamespace().Name
-
-		parts := strings.Split(ns.Name(), ".")
-		for _ = range parts {
-			file, _ = filepath.Split(file)
-			if len(file) == 0 {
-				break
-			}
-			file = file[:len(file)-1]
-		}
-		path = filepath.Join(append([]string{file}, strings.Split(sym.Name(), ".")...)...) + ".joke"
+		path = filepath.Join(append([]string{namespaceRoot()}, strings.Split(sym.Name(), ".")...)...) + ".joke"
 	}
 	return String{S: path}
 }
@@ -1615,6 +1771,7 @@ var procInternFakeVar = func(args []Object) Object {
 	isMacro := ToBool(args[2])
 	res := InternFakeSymbol(GLOBAL_ENV.FindNamespace(nsSym), sym)
 	res.isMacro = isMacro
+	noteLintReference(res, GetPosition(sym), -1)
 	return res
 }
 
@@ -1631,6 +1788,11 @@ var procParse = func(args []Object) Object {
 	return res.Dump(false)
 }
 
+var procNumCPU = func(args []Object) Object {
+	CheckArity(args, 0, 0)
+	return MakeInt(runtime.NumCPU())
+}
+
 var procTypes = func(args []Object) Object {
 	CheckArity(args, 0, 0)
 	res := EmptyArrayMap()
@@ -1653,7 +1815,7 @@ var procCloseChan = func(args []Object) Object {
 	return NIL
 }
 
-var procSend = func(args []Object) (obj Object) {
+var procSend = func(args []Object) Object {
 	CheckArity(args, 2, 2)
 	ch := EnsureArgIsChannel(args, 0)
 	v := args[1]
@@ -1663,25 +1825,46 @@ var procSend = func(args []Object) (obj Object) {
 	if ch.isClosed {
 		return MakeBoolean(false)
 	}
-	obj = MakeBoolean(true)
+	rt := RT.ReleaseGIL()
+	sent := send(ch, v, rt.limitsDone())
+	rt.AcquireGIL()
+	if sent == nil {
+		rt.checkLimits()
+	}
+	return sent
+}
+
+// send puts v onto ch unless done is closed first, in which case
+// it returns nil. Returns false if ch is closed.
+func send(ch *Channel, v Object, done <-chan struct{}) (sent Object) {
 	defer func() {
 		if r := recover(); r != nil {
-			RT.GIL.Lock()
-			obj = MakeBoolean(false)
+			sent = MakeBoolean(false)
 		}
 	}()
-	RT.GIL.Unlock()
-	ch.ch <- MakeFutureResult(v, nil)
-	RT.GIL.Lock()
-	return
+	select {
+	case ch.ch <- MakeFutureResult(v, nil):
+		return MakeBoolean(true)
+	case <-done:
+		return nil
+	}
 }
 
 var procReceive = func(args []Object) Object {
 	CheckArity(args, 1, 1)
 	ch := EnsureArgIsChannel(args, 0)
-	RT.GIL.Unlock()
-	res, ok := <-ch.ch
-	RT.GIL.Lock()
+	rt := RT.ReleaseGIL()
+	var res FutureResult
+	ok, interrupted := false, false
+	select {
+	case res, ok = <-ch.ch:
+	case <-rt.limitsDone():
+		interrupted = true
+	}
+	rt.AcquireGIL()
+	if interrupted {
+		rt.checkLimits()
+	}
 	if !ok {
 		return NIL
 	}
@@ -1691,10 +1874,98 @@ var procReceive = func(args []Object) Object {
 	return res.value
 }
 
+var procOffer = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	res := TryChannelOp(MakePutOp(EnsureArgIsChannel(args, 0), args[1]))
+	if res == nil {
+		return NIL
+	}
+	return res
+}
+
+var procPoll = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	res := TryChannelOp(MakeTakeOp(EnsureArgIsChannel(args, 0)))
+	if res == nil {
+		return NIL
+	}
+	return res
+}
+
+var procAlts = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	var ops []ChannelOp
+	for s := EnsureArgIsSeqable(args, 0).Seq(); !s.IsEmpty(); s = s.Rest() {
+		switch port := s.First().(type) {
+		case *Channel:
+			ops = append(ops, MakeTakeOp(port))
+		case Vec:
+			if port.Count() != 2 {
+				panic(RT.NewError("alts! put operation must be a vector of channel and value, got " + port.ToString(true)))
+			}
+			ch := EnsureObjectIsChannel(port.At(0), "alts! put operation must start with a channel, got %s")
+			ops = append(ops, MakePutOp(ch, port.At(1)))
+		default:
+			panic(RT.NewError("alts! operation must be a channel or a vector of channel and value, got " + port.ToString(true)))
+		}
+	}
+	priority := false
+	var defaultValue Object
+	if opts, ok := args[1].(Map); ok {
+		_, p := opts.Get(KEYWORDS.priority)
+		priority = p != nil && ToBool(p)
+		if ok, v := opts.Get(KEYWORDS._default); ok {
+			defaultValue = v
+		}
+	}
+	return Alts(ops, priority, defaultValue)
+}
+
+var procTimeout = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	ms := EnsureArgIsInt(args, 0)
+	return MakeTimeoutChannel(time.Duration(ms.I) * time.Millisecond)
+}
+
+var procFutureCall = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return MakeFuture(EnsureArgIsCallable(args, 0))
+}
+
+var procFutureCancel = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return MakeBoolean(EnsureArgIsFuture(args, 0).Cancel())
+}
+
+var procIsFutureCancelled = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return MakeBoolean(EnsureArgIsFuture(args, 0).IsCancelled())
+}
+
+var procIsFutureDone = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return MakeBoolean(EnsureArgIsFuture(args, 0).IsRealized())
+}
+
+var procPromise = func(args []Object) Object {
+	CheckArity(args, 0, 0)
+	return MakePromise()
+}
+
+var procDeliver = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	p := EnsureArgIsPromise(args, 0)
+	if p.Deliver(args[1]) {
+		return p
+	}
+	return NIL
+}
+
 var procGo = func(args []Object) Object {
 	CheckArity(args, 1, 1)
 	f := EnsureArgIsCallable(args, 0)
 	ch := MakeChannel(make(chan FutureResult, 1))
+	rt := RT.Spawn()
 	go func() {
 
 		defer func() {
@@ -1704,14 +1975,14 @@ var procGo = func(args []Object) Object {
 					ch.ch <- MakeFutureResult(NIL, r)
 					ch.Close()
 				default:
-					RT.GIL.Unlock()
+					rt.ReleaseGIL()
 					panic(r)
 				}
 			}
-			RT.GIL.Unlock()
+			rt.ReleaseGIL()
 		}()
 
-		RT.GIL.Lock()
+		rt.AcquireGIL()
 		res := f.Call([]Object{})
 		ch.ch <- MakeFutureResult(res, nil)
 		ch.Close()
@@ -1781,6 +2052,228 @@ var procParseLong = func(args []Object) Object {
 	return Int{I: int(i)}
 }
 
+var procProtocol = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	name := EnsureArgIsSymbol(args, 0)
+	var methods []Keyword
+	for s := EnsureArgIsSeqable(args, 1).Seq(); !s.IsEmpty(); s = s.Rest() {
+		methods = append(methods, EnsureObjectIsKeyword(s.First(), ""))
+	}
+	return MakeProtocol(name, methods)
+}
+
+func ensureArgIsExtendableType(args []Object, index int) *Type {
+	if args[index].Equals(NIL) {
+		return nil
+	}
+	return EnsureArgIsType(args, index)
+}
+
+var procExtend = func(args []Object) Object {
+	if len(args) < 3 || len(args)%2 == 0 {
+		panic(RT.NewError("extend expects a type followed by protocol/method map pairs"))
+	}
+	t := ensureArgIsExtendableType(args, 0)
+	for i := 1; i < len(args); i += 2 {
+		p := EnsureArgIsProtocol(args, i)
+		p.Extend(t, EnsureArgIsMap(args, i+1))
+	}
+	return NIL
+}
+
+var procProtocolMethod = func(args []Object) Object {
+	CheckArity(args, 3, 3)
+	p := EnsureArgIsProtocol(args, 0)
+	return p.Method(EnsureArgIsKeyword(args, 1), args[2])
+}
+
+var procSatisfies = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	return Boolean{B: EnsureArgIsProtocol(args, 0).Satisfies(args[1])}
+}
+
+var procExtends = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	p := EnsureArgIsProtocol(args, 0)
+	return Boolean{B: p.Extends(ensureArgIsExtendableType(args, 1))}
+}
+
+var procExtenders = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return EnsureArgIsProtocol(args, 0).Extenders()
+}
+
+func ensureArgsAreTypeNameAndFields(args []Object) (string, []Keyword) {
+	CheckArity(args, 2, 2)
+	name := EnsureArgIsSymbol(args, 0)
+	var fields []Keyword
+	for s := EnsureArgIsSeqable(args, 1).Seq(); !s.IsEmpty(); s = s.Rest() {
+		fields = append(fields, EnsureObjectIsKeyword(s.First(), ""))
+	}
+	return name.ToString(false), fields
+}
+
+var procRecordType = func(args []Object) Object {
+	return MakeRecordType(ensureArgsAreTypeNameAndFields(args))
+}
+
+var procDeftypeType = func(args []Object) Object {
+	return MakeDeftypeType(ensureArgsAreTypeNameAndFields(args))
+}
+
+func ensureArgIsRecordType(args []Object, index int) *Type {
+	t := EnsureArgIsType(args, index)
+	if !t.isRecordType() {
+		panic(RT.NewError(t.ToString(false) + " is not a record type"))
+	}
+	return t
+}
+
+// procRecord is the positional factory of both record and deftype types.
+var procRecord = func(args []Object) Object {
+	t := EnsureArgIsType(args, 0)
+	if !t.isDefinedType() {
+		panic(RT.NewError(t.ToString(false) + " is not a record or deftype type"))
+	}
+	vals := make([]Object, len(args)-1)
+	copy(vals, args[1:])
+	if t.isRecordType() {
+		return NewRecord(t, vals)
+	}
+	return NewTypeInstance(t, vals)
+}
+
+var procMapToRecord = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	t := ensureArgIsRecordType(args, 0)
+	return NewRecordFromMap(t, EnsureArgIsMap(args, 1))
+}
+
+func ensureArgIsComparatorOrNil(args []Object, index int) Comparator {
+	if args[index].Equals(NIL) {
+		return defaultComparator{}
+	}
+	return EnsureArgIsComparator(args, index)
+}
+
+var procSortedMap = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	res := NewSortedMap(ensureArgIsComparatorOrNil(args, 0))
+	for s := EnsureArgIsSeqable(args, 1).Seq(); !s.IsEmpty(); s = s.Rest().Rest() {
+		if s.Rest().IsEmpty() {
+			panic(RT.NewError("No value supplied for key " + s.First().ToString(true)))
+		}
+		res.Set(s.First(), Second(s))
+	}
+	return res
+}
+
+var procSortedSet = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	res := NewSortedSet(ensureArgIsComparatorOrNil(args, 0))
+	for s := EnsureArgIsSeqable(args, 1).Seq(); !s.IsEmpty(); s = s.Rest() {
+		res.Add(s.First())
+	}
+	return res
+}
+
+var procSortedSeqFrom = func(args []Object) Object {
+	CheckArity(args, 3, 3)
+	sc := EnsureArgIsSorted(args, 0)
+	s := sc.SeqFrom(args[1], EnsureArgIsBoolean(args, 2).B)
+	if s.IsEmpty() {
+		return NIL
+	}
+	return s
+}
+
+var procSortedCompare = func(args []Object) Object {
+	CheckArity(args, 3, 3)
+	sc := EnsureArgIsSorted(args, 0)
+	return Int{I: sc.Comparator().Compare(sc.EntryKey(args[1]), args[2])}
+}
+
+var procTransient = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return NewTransient(args[0])
+}
+
+var procPersistent = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return EnsureArgIsTransient(args, 0).Persistent()
+}
+
+var procConjBang = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	return EnsureArgIsTransient(args, 0).Conj(args[1])
+}
+
+var procConjAllBang = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	t := EnsureArgIsTransient(args, 0)
+	for s := EnsureArgIsSeqable(args, 1).Seq(); !s.IsEmpty(); s = s.Rest() {
+		t = t.Conj(s.First())
+	}
+	return t
+}
+
+var procFrequencies = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	counts := NewTransientMap(EmptyArrayMap())
+	for s := EnsureArgIsSeqable(args, 0).Seq(); !s.IsEmpty(); s = s.Rest() {
+		x := s.First()
+		n := 0
+		if ok, v := counts.Get(x); ok {
+			n = v.(Int).I
+		}
+		counts.Assoc(x, Int{I: n + 1})
+	}
+	return counts.Persistent()
+}
+
+var procGroupBy = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	f := EnsureArgIsCallable(args, 0)
+	groups := NewTransientMap(EmptyArrayMap())
+	for s := EnsureArgIsSeqable(args, 1).Seq(); !s.IsEmpty(); s = s.Rest() {
+		x := s.First()
+		k := f.Call([]Object{x})
+		group := EmptyVector()
+		if ok, v := groups.Get(k); ok {
+			group = v.(*Vector)
+		}
+		groups.Assoc(k, group.Conjoin(x))
+	}
+	return groups.Persistent()
+}
+
+var procAssocBang = func(args []Object) Object {
+	CheckArity(args, 3, 3)
+	switch t := args[0].(type) {
+	case *TransientVector:
+		return t.Assoc(args[1], args[2])
+	case *TransientMap:
+		return t.Assoc(args[1], args[2])
+	default:
+		panic(RT.NewArgTypeError(0, args[0], "TransientVector or TransientMap"))
+	}
+}
+
+var procDissocBang = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	return EnsureArgIsTransientMap(args, 0).Without(args[1])
+}
+
+var procDisjBang = func(args []Object) Object {
+	CheckArity(args, 2, 2)
+	return EnsureArgIsTransientSet(args, 0).Disjoin(args[1])
+}
+
+var procPopBang = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return EnsureArgIsTransientVector(args, 0).Pop()
+}
+
 func PackReader(reader *Reader, filename string) ([]byte, error) {
 	var p []byte
 	packEnv := NewPackEnv()
@@ -1824,11 +2317,26 @@ var procIncProblemCount = func(args []Object) Object {
 	return NIL
 }
 
+var procReportLinterProblem = func(args []Object) Object {
+	CheckArity(args, 1, 1)
+	err := EnsureArgIsError(args, 0)
+	if p, ok := lintProblemFromError(err); ok {
+		reportLintProblem(p)
+	} else {
+		PROBLEM_COUNT++
+		fmt.Fprintln(Stderr, err)
+	}
+	return NIL
+}
+
 func ProcessReader(reader *Reader, filename string, phase Phase) error {
 	if phase == FORMAT {
 		FORMAT_MODE = true
 		HASHMAP_THRESHOLD = 100000
 	}
+	if phase >= EVAL {
+		defer RT.limitEvalTime()()
+	}
 	parseContext := &ParseContext{GlobalEnv: GLOBAL_ENV}
 	if filename != "" {
 		currentFilename := parseContext.GlobalEnv.file.Value
@@ -1849,7 +2357,7 @@ func ProcessReader(reader *Reader, filename string, phase Phase) error {
 			return nil
 		}
 		if err != nil {
-			fmt.Fprintln(Stderr, err)
+			printProcessError(err)
 			return err
 		}
 		if phase == READ {
@@ -1871,7 +2379,7 @@ func ProcessReader(reader *Reader, filename string, phase Phase) error {
 		}
 		expr, err := TryParse(obj, parseContext)
 		if err != nil {
-			fmt.Fprintln(Stderr, err)
+			printProcessError(err)
 		}
 		if phase == PARSE {
 			continue
@@ -1881,7 +2389,7 @@ func ProcessReader(reader *Reader, filename string, phase Phase) error {
 		}
 		obj, err = TryEval(expr)
 		if err != nil {
-			fmt.Fprintln(Stderr, err)
+			printProcessError(err)
 			return err
 		}
 		if phase == EVAL {
@@ -1894,7 +2402,16 @@ func ProcessReader(reader *Reader, filename string, phase Phase) error {
 }
 
 func ProcessReaderFromEval(reader *Reader, filename string) {
+	processReaderFromEval(reader, filename, nil)
+}
+
+// processReaderFromEval is ProcessReaderFromEval that also packs
+// the code it evaluates for the lib cache if cache is not nil.
+func processReaderFromEval(reader *Reader, filename string, cache *libCacheWriter) {
 	parseContext := &ParseContext{GlobalEnv: GLOBAL_ENV}
+	if cache != nil {
+		parseContext.macros = cache.macros
+	}
 	if filename != "" {
 		currentFilename := parseContext.GlobalEnv.file.Value
 		defer func() {
@@ -1912,6 +2429,9 @@ func ProcessReaderFromEval(reader *Reader, filename string) {
 		PanicOnErr(err)
 		expr, err := TryParse(obj, parseContext)
 		PanicOnErr(err)
+		if cache != nil {
+			cache.add(expr)
+		}
 		obj, err = TryEval(expr)
 		PanicOnErr(err)
 	}
@@ -2008,6 +2528,9 @@ func findConfigFile(filename string, workingDir string, findDir bool) string {
 }
 
 func printConfigError(filename, msg string) {
+	if LINT_WORKER {
+		return
+	}
 	fmt.Fprintln(Stderr, "Error reading config file "+filename+": ", msg)
 }
 
@@ -2035,6 +2558,7 @@ func knownMacrosToMap(km Object) (Map, error) {
 func ReadConfig(filename string, workingDir string) {
 	LINTER_CONFIG = GLOBAL_ENV.CoreNamespace.Intern(MakeSymbol("*linter-config*"))
 	LINTER_CONFIG.Value = EmptyArrayMap()
+	resetLintSeverities()
 	configFileName := findConfigFile(filename, workingDir, false)
 	if configFileName == "" {
 		return
@@ -2129,15 +2653,20 @@ func ReadConfig(filename string, workingDir string) {
 			printConfigError(configFileName, ":rules value must be a map, got "+rules.GetType().ToString(false))
 			return
 		}
-		if ok, v := m.Get(KEYWORDS.ifWithoutElse); ok {
-			WARNINGS.ifWithoutElse = ToBool(v)
-		}
-		if ok, v := m.Get(KEYWORDS.unusedFnParameters); ok {
-			WARNINGS.unusedFnParameters = ToBool(v)
-		}
-		if ok, v := m.Get(KEYWORDS.fnWithEmptyBody); ok {
-			WARNINGS.fnWithEmptyBody = ToBool(v)
+		for iter := m.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			rule, ok := p.Key.(Keyword)
+			if !ok {
+				printConfigError(configFileName, ":rules keys must be keywords, got "+p.Key.GetType().ToString(false))
+				continue
+			}
+			if err := SetLintSeverity(rule.Name(), p.Value); err != nil {
+				printConfigError(configFileName, err.Error())
+			}
 		}
+		WARNINGS.ifWithoutElse = lintSeverity("if-without-else") != LINT_OFF
+		WARNINGS.unusedFnParameters = lintSeverity("unused-fn-parameters") != LINT_OFF
+		WARNINGS.fnWithEmptyBody = lintSeverity("fn-with-empty-body") != LINT_OFF
 	}
 	if ok, valid := configMap.Get(KEYWORDS.validIdent); ok {
 		m, ok := valid.(Map)
@@ -133,6 +133,9 @@ func init() {
 	intern("ns-unalias__", procNamespaceUnalias, "procNamespaceUnalias")
 	intern("var-get__", procVarGet, "procVarGet")
 	intern("var-set__", procVarSet, "procVarSet")
+	intern("push-thread-bindings__", procPushThreadBindings, "procPushThreadBindings")
+	intern("pop-thread-bindings__", procPopThreadBindings, "procPopThreadBindings")
+	intern("get-thread-bindings__", procGetThreadBindings, "procGetThreadBindings")
 	intern("ns-resolve__", procNsResolve, "procNsResolve")
 	intern("array-map__", procArrayMap, "procArrayMap")
 	intern("buffer__", procBuffer, "procBuffer")
@@ -155,6 +158,10 @@ func init() {
 	intern("swap-vals__", procSwapVals, "procSwapVals")
 	intern("reset__", procReset, "procReset")
 	intern("reset-vals__", procResetVals, "procResetVals")
+	intern("add-watch__", procAddWatch, "procAddWatch")
+	intern("remove-watch__", procRemoveWatch, "procRemoveWatch")
+	intern("set-validator!__", procSetValidator, "procSetValidator")
+	intern("get-validator__", procGetValidator, "procGetValidator")
 	intern("alter-meta__", procAlterMeta, "procAlterMeta")
 	intern("reset-meta__", procResetMeta, "procResetMeta")
 	intern("empty__", procEmpty, "procEmpty")
@@ -164,6 +171,9 @@ func init() {
 	intern("load-lib-from-path__", procLoadLibFromPath, "procLoadLibFromPath")
 	intern("reduce-kv__", procReduceKv, "procReduceKv")
 	intern("reduce__", procReduce, "procReduce")
+	intern("reduced__", procReduced, "procReduced")
+	intern("read-instant__", procReadInstant, "procReadInstant")
+	intern("read-uuid__", procReadUUID, "procReadUUID")
 	intern("slurp__", procSlurp, "procSlurp")
 	intern("spit__", procSpit, "procSpit")
 	intern("shuffle__", procShuffle, "procShuffle")
@@ -178,12 +188,25 @@ func init() {
 	intern("intern-fake-var__", procInternFakeVar, "procInternFakeVar")
 	intern("parse__", procParse, "procParse")
 	intern("inc-problem-count__", procIncProblemCount, "procIncProblemCount")
+	intern("report-linter-problem__", procReportLinterProblem, "procReportLinterProblem")
 	intern("types__", procTypes, "procTypes")
+	intern("num-cpu__", procNumCPU, "procNumCPU")
 	intern("go__", procGo, "procGo")
+	intern("future-call__", procFutureCall, "procFutureCall")
+	intern("with-eval-limits__", procWithEvalLimits, "procWithEvalLimits")
+	intern("future-cancel__", procFutureCancel, "procFutureCancel")
+	intern("future-cancelled?__", procIsFutureCancelled, "procIsFutureCancelled")
+	intern("future-done?__", procIsFutureDone, "procIsFutureDone")
+	intern("promise__", procPromise, "procPromise")
+	intern("deliver__", procDeliver, "procDeliver")
 	intern("<!__", procReceive, "procReceive")
 	intern(">!__", procSend, "procSend")
 	intern("chan__", procCreateChan, "procCreateChan")
 	intern("close!__", procCloseChan, "procCloseChan")
+	intern("offer!__", procOffer, "procOffer")
+	intern("poll!__", procPoll, "procPoll")
+	intern("alts!__", procAlts, "procAlts")
+	intern("timeout__", procTimeout, "procTimeout")
 
 	intern("go-spew__", procGoSpew, "procGoSpew")
 	intern("verbosity-level__", procVerbosityLevel, "procVerbosityLevel")
@@ -193,4 +216,32 @@ func init() {
 	intern("infinite?__", procIsInfinite, "procIsInfinite")
 	intern("parseDouble__", procParseDouble, "procParseDouble")
 	intern("parseLong__", procParseLong, "procParseLong")
+
+	intern("protocol__", procProtocol, "procProtocol")
+	intern("extend__", procExtend, "procExtend")
+	intern("protocol-method__", procProtocolMethod, "procProtocolMethod")
+	intern("satisfies?__", procSatisfies, "procSatisfies")
+	intern("extends?__", procExtends, "procExtends")
+	intern("extenders__", procExtenders, "procExtenders")
+
+	intern("record-type__", procRecordType, "procRecordType")
+	intern("deftype-type__", procDeftypeType, "procDeftypeType")
+	intern("record__", procRecord, "procRecord")
+	intern("map->record__", procMapToRecord, "procMapToRecord")
+
+	intern("sorted-map__", procSortedMap, "procSortedMap")
+	intern("sorted-set__", procSortedSet, "procSortedSet")
+	intern("sorted-seq-from__", procSortedSeqFrom, "procSortedSeqFrom")
+	intern("sorted-compare__", procSortedCompare, "procSortedCompare")
+
+	intern("transient__", procTransient, "procTransient")
+	intern("persistent!__", procPersistent, "procPersistent")
+	intern("conj!__", procConjBang, "procConjBang")
+	intern("conj-all!__", procConjAllBang, "procConjAllBang")
+	intern("frequencies__", procFrequencies, "procFrequencies")
+	intern("group-by__", procGroupBy, "procGroupBy")
+	intern("assoc!__", procAssocBang, "procAssocBang")
+	intern("dissoc!__", procDissocBang, "procDissocBang")
+	intern("disj!__", procDisjBang, "procDisjBang")
+	intern("pop!__", procPopBang, "procPopBang")
 }
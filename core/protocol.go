@@ -0,0 +1,185 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+type (
+	Protocol struct {
+		name    Symbol
+		methods []Keyword
+		// Guards the fields below, so that the protocol can be
+		// extended and its implementations cached without relying
+		// on the GIL.
+		mu sync.RWMutex
+		// Implementations keyed by the exact type they were extended to.
+		impls map[*Type]Map
+		// Extended types, in the order they were extended.
+		types []*Type
+		// Interface types with implementations, in the order they were extended.
+		// Object is never in this list; it is always tried last.
+		ifaces  []*Type
+		nilImpl Map
+		// Resolved implementations for concrete types, reset on every extend.
+		cache map[*Type]Map
+		hash  uint32
+	}
+)
+
+func MakeProtocol(name Symbol, methods []Keyword) *Protocol {
+	res := &Protocol{
+		name:    name,
+		methods: methods,
+		impls:   make(map[*Type]Map),
+		cache:   make(map[*Type]Map),
+	}
+	res.hash = HashPtr(uintptr(unsafe.Pointer(res)))
+	return res
+}
+
+func (p *Protocol) ToString(escape bool) string {
+	return "#object[Protocol " + p.name.ToString(false) + "]"
+}
+
+func (p *Protocol) Equals(other interface{}) bool {
+	return p == other
+}
+
+func (p *Protocol) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (p *Protocol) GetType() *Type {
+	return TYPE.Protocol
+}
+
+func (p *Protocol) Hash() uint32 {
+	return p.hash
+}
+
+func (p *Protocol) WithInfo(info *ObjectInfo) Object {
+	return p
+}
+
+func (p *Protocol) hasMethod(k Keyword) bool {
+	for _, m := range p.methods {
+		if m.Equals(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Extend registers method implementations (a map of method keywords
+// to functions) for type t. t is nil to extend the protocol to nil.
+func (p *Protocol) Extend(t *Type, impl Map) {
+	for iter := impl.Iter(); iter.HasNext(); {
+		entry := iter.Next()
+		k, ok := entry.Key.(Keyword)
+		if !ok || !p.hasMethod(k) {
+			panic(RT.NewError(fmt.Sprintf("%s is not a method of protocol %s", entry.Key.ToString(true), p.name.ToString(false))))
+		}
+		if _, ok := entry.Value.(Callable); !ok {
+			panic(RT.NewError(fmt.Sprintf("Implementation of %s for protocol %s must be a function, got %s",
+				k.ToString(false), p.name.ToString(false), entry.Value.GetType().ToString(false))))
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t == nil {
+		p.nilImpl = impl
+		return
+	}
+	if _, ok := p.impls[t]; !ok {
+		p.types = append(p.types, t)
+		if t != TYPE.Object && t.reflectType.Kind() == reflect.Interface {
+			p.ifaces = append(p.ifaces, t)
+		}
+	}
+	p.impls[t] = impl
+	p.cache = make(map[*Type]Map)
+}
+
+func (p *Protocol) findImpl(obj Object) Map {
+	if obj.Equals(NIL) {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.nilImpl
+	}
+	t := obj.GetType()
+	p.mu.RLock()
+	impl, ok := p.impls[t]
+	if !ok {
+		impl, ok = p.cache[t]
+	}
+	p.mu.RUnlock()
+	if ok {
+		return impl
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var res Map
+	if IsEqualOrImplements(TYPE.Record, t) {
+		// Types defined via defrecord share the Go type of Record,
+		// so an implementation for Record covers all of them.
+		res = p.impls[TYPE.Record]
+	}
+	if res == nil {
+		for _, iface := range p.ifaces {
+			if IsEqualOrImplements(iface, t) {
+				res = p.impls[iface]
+				break
+			}
+		}
+	}
+	if res == nil {
+		res = p.impls[TYPE.Object]
+	}
+	p.cache[t] = res
+	return res
+}
+
+func (p *Protocol) Satisfies(obj Object) bool {
+	return p.findImpl(obj) != nil
+}
+
+func (p *Protocol) Extends(t *Type) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if t == nil {
+		return p.nilImpl != nil
+	}
+	_, ok := p.impls[t]
+	return ok
+}
+
+func (p *Protocol) Extenders() Seq {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	res := EmptyVector()
+	if p.nilImpl != nil {
+		res = res.Conjoin(NIL)
+	}
+	for _, t := range p.types {
+		res = res.Conjoin(t)
+	}
+	return res.Seq()
+}
+
+// Method returns the implementation of method k for the type of obj.
+func (p *Protocol) Method(k Keyword, obj Object) Object {
+	if impl := p.findImpl(obj); impl != nil {
+		if ok, f := impl.Get(k); ok {
+			return f
+		}
+	}
+	typeName := "nil"
+	if !obj.Equals(NIL) {
+		typeName = obj.GetType().ToString(false)
+	}
+	panic(RT.NewError(fmt.Sprintf("No implementation of method: %s of protocol: %s found for type: %s",
+		k.ToString(false), p.name.ToString(false), typeName)))
+}
@@ -219,7 +219,8 @@ func eatWhitespace(reader *Reader) {
 		}
 		if r == '#' && reader.Peek() == '_' && !FORMAT_MODE {
 			reader.Get()
-			Read(reader)
+			obj, _ := Read(reader)
+			addLintSuppression(reader, obj)
 			r = reader.Get()
 			continue
 		}
@@ -453,7 +454,7 @@ func readIdent(reader *Reader, first rune) Object {
 			if ns == nil {
 				msg := fmt.Sprintf("Unable to resolve namespace %s in keyword %s", *sym.ns, ":"+str)
 				if LINTER_MODE {
-					printReadWarning(reader, msg)
+					printReadWarning(reader, "unresolved-namespace", msg)
 					return MakeReadObject(reader, MakeKeyword(*sym.name))
 				}
 				panic(MakeReadError(reader, msg))
@@ -556,7 +557,7 @@ func warnInvalidIdent(reader *Reader, s *string) {
 				explain = identValidationSetWhy + "; " + identValidationRangeWhy
 			}
 			msg := fmt.Sprintf("Impermissible character %q at %d in %q (%s)", r, k, *s, explain)
-			printReadWarning(reader, msg)
+			printReadWarning(reader, "invalid-ident", msg)
 		}
 		k++
 	}
@@ -734,7 +735,7 @@ func readMulti(reader *Reader, previouslyRead []Object) (Object, []Object) {
 
 func readError(reader *Reader, msg string) {
 	if LINTER_MODE {
-		printReadError(reader, msg)
+		printReadError(reader, "reader-conditional", msg)
 	} else {
 		panic(MakeReadError(reader, msg))
 	}
@@ -888,6 +889,10 @@ func readMapWithNamespace(reader *Reader, nsname string) Object {
 		r = reader.Peek()
 	}
 	reader.Get()
+	return makeReadMap(reader, objs, nsname)
+}
+
+func makeReadMap(reader *Reader, objs []Object, nsname string) Object {
 	if len(objs)%2 != 0 {
 		panic(MakeReadError(reader, "Map literal must contain an even number of forms"))
 	}
@@ -1138,7 +1143,7 @@ func handleNoReaderError(reader *Reader, s Symbol) Object {
 	}
 	if LINTER_MODE {
 		if DIALECT != EDN {
-			printReadWarning(reader, "No reader function for tag "+s.ToString(false))
+			printReadWarning(reader, "unknown-tag", "No reader function for tag "+s.ToString(false))
 		}
 		return readFirst(reader)
 	}
@@ -1154,19 +1159,30 @@ func readTagged(reader *Reader) Object {
 	}
 	switch s := obj.(type) {
 	case Symbol:
-		readersVar, ok := GLOBAL_ENV.CoreNamespace.mappings[SYMBOLS.defaultDataReaders.name]
-		if !ok {
-			return handleNoReaderError(reader, s)
+		if SUPPRESS_READ {
+			return readFirst(reader)
 		}
-		readersMap, ok := readersVar.Value.(Map)
-		if !ok {
-			return handleNoReaderError(reader, s)
+		if readFunc := coreDataReader(SYMBOLS.dataReaders, s); readFunc != nil {
+			return EnsureObjectIsCallable(readFunc, "Data reader for tag "+s.ToString(false)+" must be a function, got %s").Call([]Object{readFirst(reader)})
 		}
-		ok, readFunc := readersMap.Get(s)
-		if !ok {
-			return handleNoReaderError(reader, s)
+		if readSym, filename := classPathDataReader(s); readSym != nil {
+			if LINTER_MODE {
+				return readFirst(reader)
+			}
+			sym, ok := readSym.(Symbol)
+			if !ok {
+				panic(MakeReadError(reader, "Data reader for tag "+s.ToString(false)+" in "+filename+" must be a symbol"))
+			}
+			return resolveDataReader(reader, sym, filename).Call([]Object{readFirst(reader)})
+		}
+		if readFunc := coreDataReader(SYMBOLS.defaultDataReaders, s); readFunc != nil {
+			return EnsureObjectIsCallable(readFunc, "Data reader for tag "+s.ToString(false)+" must be a function, got %s").Call([]Object{readFirst(reader)})
 		}
-		return EnsureObjectIsVar(readFunc, "").Call([]Object{readFirst(reader)})
+		if v, ok := GLOBAL_ENV.CoreNamespace.mappings[SYMBOLS.defaultReaderFn.name]; ok && v.Value != nil && !v.Value.Equals(NIL) {
+			f := EnsureObjectIsCallable(v.Value, "*default-data-reader-fn* must be a function, got %s")
+			return f.Call([]Object{s, readFirst(reader)})
+		}
+		return handleNoReaderError(reader, s)
 	default:
 		panic(MakeReadError(reader, "Reader tag must be a symbol"))
 	}
@@ -1362,7 +1378,10 @@ func readDispatch(reader *Reader) (Object, bool) {
 
 func readWithMeta(reader *Reader) Object {
 	meta := readMeta(reader)
+	n := len(lintSuppressions)
+	addLintSuppression(reader, meta)
 	nextObj := readFirst(reader)
+	closeLintSuppressions(reader, n)
 	switch v := nextObj.(type) {
 	case Meta:
 		return DeriveReadObject(nextObj, v.WithMeta(meta))
@@ -1388,7 +1407,14 @@ func addPrefix(obj Object, prefix string) {
 }
 
 func Read(reader *Reader) (Object, bool) {
+	n := len(lintSuppressions)
 	eatWhitespace(reader)
+	if len(lintSuppressions) > n {
+		// #_{:joker/ignore ...} applies to the form that follows.
+		obj, multi := Read(reader)
+		closeLintSuppressions(reader, n)
+		return obj, multi
+	}
 	r := reader.Get()
 	pushPos(reader)
 	// This is only possible in format mode, otherwise
@@ -1505,6 +1531,10 @@ func TryRead(reader *Reader) (obj Object, err error) {
 				err = r.(error)
 			case *EvalError:
 				err = r.(error)
+			case *StackOverflow:
+				err = r.(error)
+			case *LimitExceeded:
+				err = r.(error)
 			case *ExInfo:
 				err = r.(error)
 			default:
@@ -1513,11 +1543,13 @@ func TryRead(reader *Reader) (obj Object, err error) {
 		}
 	}()
 	for {
+		n := len(lintSuppressions)
 		eatWhitespace(reader)
 		if reader.Peek() == EOF {
 			return NIL, io.EOF
 		}
 		obj, multi := Read(reader)
+		closeLintSuppressions(reader, n)
 		if !multi {
 			return obj, nil
 		}
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+type (
+	// Record is an instance of a type defined via defrecord.
+	// Values of the declared fields are stored positionally; any other
+	// keys assoc'ed onto the record go to ext.
+	Record struct {
+		MetaHolder
+		rtype *Type
+		vals  []Object
+		ext   Map
+	}
+	// TypeInstance is an instance of a type defined via deftype.
+	// Unlike records, it is not a map and is only equal to itself.
+	TypeInstance struct {
+		rtype *Type
+		vals  []Object
+		hash  uint32
+	}
+)
+
+var (
+	recordReflectType       = reflect.TypeOf((*Record)(nil))
+	typeInstanceReflectType = reflect.TypeOf((*TypeInstance)(nil))
+)
+
+func makeDefinedType(name string, fields []Keyword, kind string, reflectType reflect.Type) *Type {
+	meta := MakeMeta(nil, "("+kind+" type)", "1.8")
+	meta.Add(KEYWORDS.name, MakeString(name))
+	if fields == nil {
+		fields = []Keyword{}
+	}
+	return &Type{
+		MetaHolder:  MetaHolder{meta},
+		name:        name,
+		reflectType: reflectType,
+		fields:      fields,
+	}
+}
+
+func MakeRecordType(name string, fields []Keyword) *Type {
+	return makeDefinedType(name, fields, "Record", recordReflectType)
+}
+
+func MakeDeftypeType(name string, fields []Keyword) *Type {
+	return makeDefinedType(name, fields, "Deftype", typeInstanceReflectType)
+}
+
+// isDefinedType returns true for types defined via defrecord or deftype.
+func (t *Type) isDefinedType() bool {
+	return t.fields != nil
+}
+
+func (t *Type) isRecordType() bool {
+	return t.isDefinedType() && t.reflectType == recordReflectType
+}
+
+func fieldIndex(t *Type, key Object) int {
+	if k, ok := key.(Keyword); ok {
+		for i, f := range t.fields {
+			if f.Equals(k) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func NewRecord(t *Type, vals []Object) *Record {
+	if len(vals) != len(t.fields) {
+		panic(RT.NewError(fmt.Sprintf("Wrong number of field values (%d) passed to %s; expects %d", len(vals), t.name, len(t.fields))))
+	}
+	return &Record{rtype: t, vals: vals}
+}
+
+func NewRecordFromMap(t *Type, m Map) *Record {
+	res := &Record{rtype: t, vals: make([]Object, len(t.fields))}
+	for i := range res.vals {
+		res.vals[i] = NIL
+	}
+	for iter := m.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		res = res.Assoc(p.Key, p.Value).(*Record)
+	}
+	return res
+}
+
+func (r *Record) fieldIndex(key Object) int {
+	return fieldIndex(r.rtype, key)
+}
+
+func (r *Record) toArrayMap() *ArrayMap {
+	arr := make([]Object, 0, r.Count()*2)
+	for i, f := range r.rtype.fields {
+		arr = append(arr, f, r.vals[i])
+	}
+	if r.ext != nil {
+		for iter := r.ext.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			arr = append(arr, p.Key, p.Value)
+		}
+	}
+	return &ArrayMap{arr: arr}
+}
+
+func (r *Record) ToString(escape bool) string {
+	return "#" + r.rtype.name + mapToString(r, escape)
+}
+
+func (r *Record) Equals(other interface{}) bool {
+	switch other := other.(type) {
+	case *Record:
+		return r.rtype == other.rtype && mapEquals(r, other)
+	default:
+		return false
+	}
+}
+
+func (r *Record) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (r *Record) WithInfo(info *ObjectInfo) Object {
+	return r
+}
+
+func (r *Record) GetType() *Type {
+	return r.rtype
+}
+
+func (r *Record) Hash() uint32 {
+	return hashUnordered(r.Seq(), 1)
+}
+
+func (r *Record) WithMeta(meta Map) Object {
+	res := *r
+	res.meta = SafeMerge(res.meta, meta)
+	return &res
+}
+
+func (r *Record) Get(key Object) (bool, Object) {
+	if i := r.fieldIndex(key); i != -1 {
+		return true, r.vals[i]
+	}
+	if r.ext != nil {
+		return r.ext.Get(key)
+	}
+	return false, nil
+}
+
+func (r *Record) EntryAt(key Object) *ArrayVector {
+	if ok, v := r.Get(key); ok {
+		return NewArrayVectorFrom(key, v)
+	}
+	return nil
+}
+
+func (r *Record) Assoc(key, value Object) Associative {
+	res := *r
+	if i := r.fieldIndex(key); i != -1 {
+		res.vals = make([]Object, len(r.vals))
+		copy(res.vals, r.vals)
+		res.vals[i] = value
+		return &res
+	}
+	if r.ext == nil {
+		res.ext = EmptyArrayMap().Assoc(key, value).(Map)
+	} else {
+		res.ext = r.ext.Assoc(key, value).(Map)
+	}
+	return &res
+}
+
+// Without returns a plain map if key is one of the record's fields,
+// since the result can no longer be an instance of the record type.
+func (r *Record) Without(key Object) Map {
+	if r.fieldIndex(key) != -1 {
+		res := r.toArrayMap().Without(key).(*ArrayMap)
+		res.meta = r.meta
+		return res
+	}
+	if r.ext == nil {
+		return r
+	}
+	res := *r
+	res.ext = r.ext.Without(key)
+	if res.ext.Count() == 0 {
+		res.ext = nil
+	}
+	return &res
+}
+
+func (r *Record) Conj(obj Object) Conjable {
+	return mapConj(r, obj)
+}
+
+func (r *Record) Count() int {
+	n := len(r.vals)
+	if r.ext != nil {
+		n += r.ext.Count()
+	}
+	return n
+}
+
+func (r *Record) Seq() Seq {
+	return r.toArrayMap().Seq()
+}
+
+func (r *Record) Keys() Seq {
+	return r.toArrayMap().Keys()
+}
+
+func (r *Record) Vals() Seq {
+	return r.toArrayMap().Vals()
+}
+
+func (r *Record) Iter() MapIterator {
+	return r.toArrayMap().Iter()
+}
+
+func (r *Record) Merge(other Map) Map {
+	var res Associative = r
+	for iter := other.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		res = res.Assoc(p.Key, p.Value)
+	}
+	return res.(Map)
+}
+
+func (r *Record) Pprint(w io.Writer, indent int) int {
+	fmt.Fprint(w, "#"+r.rtype.name)
+	return pprintMap(r, w, indent+len(r.rtype.name)+1)
+}
+
+func NewTypeInstance(t *Type, vals []Object) *TypeInstance {
+	if len(vals) != len(t.fields) {
+		panic(RT.NewError(fmt.Sprintf("Wrong number of field values (%d) passed to %s; expects %d", len(vals), t.name, len(t.fields))))
+	}
+	res := &TypeInstance{rtype: t, vals: vals}
+	res.hash = HashPtr(uintptr(unsafe.Pointer(res)))
+	return res
+}
+
+func (x *TypeInstance) ToString(escape bool) string {
+	return "#object[" + x.rtype.name + "]"
+}
+
+func (x *TypeInstance) Equals(other interface{}) bool {
+	return x == other
+}
+
+func (x *TypeInstance) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (x *TypeInstance) WithInfo(info *ObjectInfo) Object {
+	return x
+}
+
+func (x *TypeInstance) GetType() *Type {
+	return x.rtype
+}
+
+func (x *TypeInstance) Hash() uint32 {
+	return x.hash
+}
+
+// Get looks up a field by its keyword, since Joker has no other
+// syntax for accessing fields.
+func (x *TypeInstance) Get(key Object) (bool, Object) {
+	if i := fieldIndex(x.rtype, key); i != -1 {
+		return true, x.vals[i]
+	}
+	return false, nil
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sandboxPolicy says which vars sandboxed code may use
+// and which directories it may access files in.
+// Entries of allow and deny are names of namespaces (e.g. "joker.os")
+// or vars (e.g. "joker.os/sh"). Entries for vars take precedence
+// over entries for their namespaces.
+type sandboxPolicy struct {
+	allow map[string]bool
+	deny  map[string]bool
+	// Absolute paths, with symlinks resolved.
+	paths []string
+}
+
+// SANDBOX is the policy enforced in sandbox mode
+// (see --sandbox command line option), or nil.
+var SANDBOX *sandboxPolicy
+
+// Denied unless allowed in the :sandbox section of .joker.
+var sandboxDefaultDeny = []string{
+	"joker.bolt",
+	"joker.filepath",
+	"joker.git",
+	"joker.http",
+	"joker.os",
+}
+
+func (p *sandboxPolicy) denies(vr *Var) bool {
+	if vr.ns == nil {
+		return false
+	}
+	if name := vr.Name(); p.deny[name] {
+		return true
+	} else if p.allow[name] {
+		return false
+	}
+	return p.deny[vr.ns.Name.ToString(false)]
+}
+
+func (p *sandboxPolicy) allowsPath(path string) bool {
+	path, err := realPath(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range p.paths {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath returns the absolute path of the file at path
+// with symlinks resolved. The file itself may not exist.
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		return p, nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(path)), nil
+}
+
+// seal makes the vars of ns denied by p unusable:
+// their values, if callable, throw an error when called.
+func (p *sandboxPolicy) seal(ns *Namespace) {
+	for _, vr := range ns.mappings {
+		if vr.ns != ns || !p.denies(vr) {
+			continue
+		}
+		if _, ok := vr.Value.(Callable); ok {
+			name := vr.Name()
+			vr.Value = Proc{
+				Fn: func(args []Object) Object {
+					panic(RT.NewError(name + " is denied by sandbox policy"))
+				},
+				Name: "sandbox-denied",
+			}
+		}
+	}
+}
+
+// sealNamespace enforces the sandbox policy, if any,
+// on vars of ns once they are interned.
+func sealNamespace(ns *Namespace) {
+	if SANDBOX != nil {
+		SANDBOX.seal(ns)
+	}
+}
+
+// checkSandbox panics with a parse error if the sandbox policy
+// denies the use of vr, referred to by obj.
+func checkSandbox(vr *Var, obj Object) {
+	if SANDBOX != nil && SANDBOX.denies(vr) {
+		panic(&ParseError{obj: obj, msg: vr.Name() + " is denied by sandbox policy"})
+	}
+}
+
+// sandboxPathError returns an error if the sandbox policy
+// doesn't allow accessing the file at path, nil otherwise.
+func sandboxPathError(path string) error {
+	if SANDBOX != nil && !SANDBOX.allowsPath(path) {
+		return errors.New("Access to " + path + " is denied by sandbox policy")
+	}
+	return nil
+}
+
+// checkSandboxPath throws an error if the sandbox policy
+// doesn't allow accessing the file at path.
+func checkSandboxPath(path string) {
+	PanicOnErr(sandboxPathError(path))
+}
+
+// EnableSandbox turns on sandbox mode. The policy is read from
+// the :sandbox section of the .joker file found starting from
+// the current directory (rather than the directory of the code
+// being run, which could then loosen the policy itself), e.g.:
+//
+//	{:sandbox {:allow [joker.filepath/join]
+//	           :deny [joker.io]
+//	           :paths ["data"]}}
+//
+// Without it, namespaces in sandboxDefaultDeny are denied and no
+// files may be accessed with slurp, spit, load-file, require or
+// the reader (data_readers files). :allow and :deny add to the
+// defaults (:deny wins if a name is in both), and :paths (relative
+// to the .joker file) lists directories whose files may be accessed.
+func EnableSandbox() error {
+	p := &sandboxPolicy{
+		allow: make(map[string]bool),
+		deny:  make(map[string]bool),
+	}
+	for _, name := range sandboxDefaultDeny {
+		p.deny[name] = true
+	}
+	if configFileName := findConfigFile("", ".", false); configFileName != "" {
+		if err := p.read(configFileName); err != nil {
+			return fmt.Errorf("Error reading config file %s: %s", configFileName, err)
+		}
+	}
+	SANDBOX = p
+	// Cached libs would bypass checks done while parsing.
+	LIB_CACHE = false
+	for _, ns := range GLOBAL_ENV.Namespaces {
+		if ns.Lazy == nil {
+			p.seal(ns)
+		}
+	}
+	return nil
+}
+
+func (p *sandboxPolicy) read(configFileName string) error {
+	f, err := os.Open(configFileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	config, err := TryRead(NewReader(bufio.NewReader(f), configFileName))
+	if err != nil {
+		return err
+	}
+	configMap, ok := config.(Map)
+	if !ok {
+		return fmt.Errorf("config root object must be a map, got %s", config.GetType().ToString(false))
+	}
+	ok, sandbox := configMap.Get(MakeKeyword("sandbox"))
+	if !ok {
+		return nil
+	}
+	m, ok := sandbox.(Map)
+	if !ok {
+		return fmt.Errorf(":sandbox value must be a map, got %s", sandbox.GetType().ToString(false))
+	}
+	allow, err := sandboxEntries(m, "allow")
+	if err != nil {
+		return err
+	}
+	for _, name := range allow {
+		delete(p.deny, name)
+		p.allow[name] = true
+	}
+	deny, err := sandboxEntries(m, "deny")
+	if err != nil {
+		return err
+	}
+	for _, name := range deny {
+		delete(p.allow, name)
+		p.deny[name] = true
+	}
+	paths, err := sandboxEntries(m, "paths")
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(configFileName), path)
+		}
+		path, err := realPath(path)
+		if err != nil {
+			return err
+		}
+		p.paths = append(p.paths, path)
+	}
+	return nil
+}
+
+// sandboxEntries returns the names of symbols (or strings, for :paths)
+// in the vector under key in m.
+func sandboxEntries(m Map, key string) ([]string, error) {
+	ok, v := m.Get(MakeKeyword(key))
+	if !ok {
+		return nil, nil
+	}
+	seqable, ok := v.(Seqable)
+	if !ok {
+		return nil, fmt.Errorf(":%s value (in :sandbox) must be a vector, got %s", key, v.GetType().ToString(false))
+	}
+	var res []string
+	for s := seqable.Seq(); !s.IsEmpty(); s = s.Rest() {
+		switch obj := s.First().(type) {
+		case Symbol:
+			if key != "paths" {
+				res = append(res, obj.ToString(false))
+				continue
+			}
+		case String:
+			if key == "paths" {
+				res = append(res, obj.S)
+				continue
+			}
+		}
+		expected := "symbols"
+		if key == "paths" {
+			expected = "strings"
+		}
+		return nil, fmt.Errorf(":%s elements (in :sandbox) must be %s, got %s", key, expected, s.First().GetType().ToString(false))
+	}
+	return res, nil
+}
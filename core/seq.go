@@ -327,6 +327,7 @@ func (seq *ConsSeq) Cons(obj Object) Seq {
 func (seq *ConsSeq) sequential() {}
 
 func NewConsSeq(first Object, rest Seq) *ConsSeq {
+	chargeAlloc(allocElem)
 	return &ConsSeq{
 		first: first,
 		rest:  rest,
@@ -69,6 +69,8 @@ func (set *MapSet) Equals(other interface{}) bool {
 	switch otherSet := other.(type) {
 	case *MapSet:
 		return set.m.Equals(otherSet.m)
+	case *SortedSet:
+		return set.m.Equals(otherSet.m)
 	default:
 		return false
 	}
@@ -0,0 +1,555 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+type (
+	Sorted interface {
+		Comparator() Comparator
+		EntryKey(entry Object) Object
+		SeqFrom(key Object, ascending bool) Seq
+	}
+	// Node of a persistent AVL tree. Nodes are never modified
+	// once they are reachable from a SortedMap.
+	sortedMapNode struct {
+		key    Object
+		value  Object
+		left   *sortedMapNode
+		right  *sortedMapNode
+		height int
+	}
+	SortedMap struct {
+		InfoHolder
+		MetaHolder
+		root  *sortedMapNode
+		count int
+		cmp   Comparator
+	}
+	SortedSet struct {
+		InfoHolder
+		MetaHolder
+		m *SortedMap
+	}
+	// Immutable stack of nodes that still have to be visited, so that
+	// seqs over sorted collections can share their tails.
+	sortedMapStack struct {
+		node *sortedMapNode
+		next *sortedMapStack
+	}
+	SortedSeq struct {
+		InfoHolder
+		MetaHolder
+		stack     *sortedMapStack
+		ascending bool
+		mode      sortedSeqMode
+	}
+	SortedMapIterator struct {
+		stack *sortedMapStack
+	}
+	sortedSeqMode int
+	// Orders objects the same way compare does.
+	defaultComparator struct{}
+)
+
+const (
+	sortedSeqEntries sortedSeqMode = iota
+	sortedSeqKeys
+	sortedSeqVals
+)
+
+func (c defaultComparator) Compare(a, b Object) int {
+	return compareObjects(a, b)
+}
+
+func (n *sortedMapNode) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func newSortedMapNode(key, value Object, left, right *sortedMapNode) *sortedMapNode {
+	h := left.getHeight()
+	if rh := right.getHeight(); rh > h {
+		h = rh
+	}
+	return &sortedMapNode{key: key, value: value, left: left, right: right, height: h + 1}
+}
+
+func rotateRight(n *sortedMapNode) *sortedMapNode {
+	l := n.left
+	return newSortedMapNode(l.key, l.value, l.left, newSortedMapNode(n.key, n.value, l.right, n.right))
+}
+
+func rotateLeft(n *sortedMapNode) *sortedMapNode {
+	r := n.right
+	return newSortedMapNode(r.key, r.value, newSortedMapNode(n.key, n.value, n.left, r.left), r.right)
+}
+
+func balanceSortedMapNode(key, value Object, left, right *sortedMapNode) *sortedMapNode {
+	n := newSortedMapNode(key, value, left, right)
+	switch d := left.getHeight() - right.getHeight(); {
+	case d > 1:
+		if left.left.getHeight() < left.right.getHeight() {
+			n = newSortedMapNode(key, value, rotateLeft(left), right)
+		}
+		return rotateRight(n)
+	case d < -1:
+		if right.right.getHeight() < right.left.getHeight() {
+			n = newSortedMapNode(key, value, left, rotateRight(right))
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+func (m *SortedMap) insert(n *sortedMapNode, key, value Object) (*sortedMapNode, bool) {
+	if n == nil {
+		return newSortedMapNode(key, value, nil, nil), true
+	}
+	c := m.cmp.Compare(key, n.key)
+	switch {
+	case c < 0:
+		l, added := m.insert(n.left, key, value)
+		return balanceSortedMapNode(n.key, n.value, l, n.right), added
+	case c > 0:
+		r, added := m.insert(n.right, key, value)
+		return balanceSortedMapNode(n.key, n.value, n.left, r), added
+	}
+	return newSortedMapNode(n.key, value, n.left, n.right), false
+}
+
+func removeMinSortedMapNode(n *sortedMapNode) (min *sortedMapNode, rest *sortedMapNode) {
+	if n.left == nil {
+		return n, n.right
+	}
+	min, l := removeMinSortedMapNode(n.left)
+	return min, balanceSortedMapNode(n.key, n.value, l, n.right)
+}
+
+func (m *SortedMap) remove(n *sortedMapNode, key Object) (*sortedMapNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+	c := m.cmp.Compare(key, n.key)
+	switch {
+	case c < 0:
+		l, removed := m.remove(n.left, key)
+		if !removed {
+			return n, false
+		}
+		return balanceSortedMapNode(n.key, n.value, l, n.right), true
+	case c > 0:
+		r, removed := m.remove(n.right, key)
+		if !removed {
+			return n, false
+		}
+		return balanceSortedMapNode(n.key, n.value, n.left, r), true
+	}
+	if n.left == nil {
+		return n.right, true
+	}
+	if n.right == nil {
+		return n.left, true
+	}
+	min, r := removeMinSortedMapNode(n.right)
+	return balanceSortedMapNode(min.key, min.value, n.left, r), true
+}
+
+func (m *SortedMap) find(key Object) *sortedMapNode {
+	n := m.root
+	for n != nil {
+		c := m.cmp.Compare(key, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+func pushSortedMapNodes(stack *sortedMapStack, n *sortedMapNode, ascending bool) *sortedMapStack {
+	for n != nil {
+		stack = &sortedMapStack{node: n, next: stack}
+		if ascending {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return stack
+}
+
+func NewSortedMap(cmp Comparator) *SortedMap {
+	return &SortedMap{cmp: cmp}
+}
+
+func EmptySortedMap() *SortedMap {
+	return NewSortedMap(defaultComparator{})
+}
+
+func (m *SortedMap) Set(key, value Object) {
+	root, added := m.insert(m.root, key, value)
+	m.root = root
+	if added {
+		chargeAlloc(allocEntry)
+		m.count++
+	}
+}
+
+func (m *SortedMap) WithMeta(meta Map) Object {
+	res := *m
+	res.meta = SafeMerge(res.meta, meta)
+	return &res
+}
+
+func (m *SortedMap) Comparator() Comparator {
+	return m.cmp
+}
+
+func (m *SortedMap) EntryKey(entry Object) Object {
+	return EnsureObjectIsVec(entry, "").At(0)
+}
+
+func (m *SortedMap) Get(key Object) (bool, Object) {
+	if n := m.find(key); n != nil {
+		return true, n.value
+	}
+	return false, nil
+}
+
+func (m *SortedMap) EntryAt(key Object) *ArrayVector {
+	if n := m.find(key); n != nil {
+		return NewArrayVectorFrom(n.key, n.value)
+	}
+	return nil
+}
+
+func (m *SortedMap) Assoc(key, value Object) Associative {
+	res := *m
+	res.Set(key, value)
+	return &res
+}
+
+func (m *SortedMap) Without(key Object) Map {
+	root, removed := m.remove(m.root, key)
+	if !removed {
+		return m
+	}
+	res := *m
+	res.root = root
+	res.count--
+	return &res
+}
+
+func (m *SortedMap) Merge(other Map) Map {
+	if other.Count() == 0 {
+		return m
+	}
+	res := *m
+	for iter := other.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		res.Set(p.Key, p.Value)
+	}
+	return &res
+}
+
+func (m *SortedMap) Conj(obj Object) Conjable {
+	return mapConj(m, obj)
+}
+
+func (m *SortedMap) Count() int {
+	return m.count
+}
+
+func (m *SortedMap) seq(mode sortedSeqMode, ascending bool) *SortedSeq {
+	return &SortedSeq{stack: pushSortedMapNodes(nil, m.root, ascending), ascending: ascending, mode: mode}
+}
+
+func (m *SortedMap) Seq() Seq {
+	return m.seq(sortedSeqEntries, true)
+}
+
+func (m *SortedMap) Rseq() Seq {
+	return m.seq(sortedSeqEntries, false)
+}
+
+// seqFrom returns a seq starting from key (or the closest entry
+// following it in the given order if key is not in the map).
+func (m *SortedMap) seqFrom(key Object, ascending bool, mode sortedSeqMode) Seq {
+	var stack *sortedMapStack
+	for n := m.root; n != nil; {
+		c := m.cmp.Compare(key, n.key)
+		if c == 0 {
+			stack = &sortedMapStack{node: n, next: stack}
+			break
+		}
+		if ascending == (c < 0) {
+			stack = &sortedMapStack{node: n, next: stack}
+			if ascending {
+				n = n.left
+			} else {
+				n = n.right
+			}
+		} else if ascending {
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return &SortedSeq{stack: stack, ascending: ascending, mode: mode}
+}
+
+func (m *SortedMap) SeqFrom(key Object, ascending bool) Seq {
+	return m.seqFrom(key, ascending, sortedSeqEntries)
+}
+
+func (m *SortedMap) Keys() Seq {
+	return m.seq(sortedSeqKeys, true)
+}
+
+func (m *SortedMap) Vals() Seq {
+	return m.seq(sortedSeqVals, true)
+}
+
+func (m *SortedMap) Iter() MapIterator {
+	return &SortedMapIterator{stack: pushSortedMapNodes(nil, m.root, true)}
+}
+
+func (m *SortedMap) ToString(escape bool) string {
+	return mapToString(m, escape)
+}
+
+func (m *SortedMap) Equals(other interface{}) bool {
+	return mapEquals(m, other)
+}
+
+func (m *SortedMap) GetType() *Type {
+	return TYPE.SortedMap
+}
+
+func (m *SortedMap) Hash() uint32 {
+	return hashUnordered(m.Seq(), 1)
+}
+
+func (m *SortedMap) Call(args []Object) Object {
+	return callMap(m, args)
+}
+
+func (m *SortedMap) Empty() Collection {
+	res := NewSortedMap(m.cmp)
+	res.meta = m.meta
+	return res
+}
+
+func (m *SortedMap) Pprint(w io.Writer, indent int) int {
+	return pprintMap(m, w, indent)
+}
+
+func (iter *SortedMapIterator) HasNext() bool {
+	return iter.stack != nil
+}
+
+func (iter *SortedMapIterator) Next() *Pair {
+	n := iter.stack.node
+	iter.stack = pushSortedMapNodes(iter.stack.next, n.right, true)
+	return &Pair{Key: n.key, Value: n.value}
+}
+
+func (seq *SortedSeq) sequential() {}
+
+func (seq *SortedSeq) Equals(other interface{}) bool {
+	return IsSeqEqual(seq, other)
+}
+
+func (seq *SortedSeq) ToString(escape bool) string {
+	return SeqToString(seq, escape)
+}
+
+func (seq *SortedSeq) Pprint(w io.Writer, indent int) int {
+	return pprintSeq(seq, w, indent)
+}
+
+func (seq *SortedSeq) WithMeta(meta Map) Object {
+	res := *seq
+	res.meta = SafeMerge(res.meta, meta)
+	return &res
+}
+
+func (seq *SortedSeq) GetType() *Type {
+	return TYPE.SortedSeq
+}
+
+func (seq *SortedSeq) Hash() uint32 {
+	return hashOrdered(seq)
+}
+
+func (seq *SortedSeq) Seq() Seq {
+	return seq
+}
+
+func (seq *SortedSeq) First() Object {
+	if seq.stack == nil {
+		return NIL
+	}
+	n := seq.stack.node
+	switch seq.mode {
+	case sortedSeqKeys:
+		return n.key
+	case sortedSeqVals:
+		return n.value
+	}
+	return NewVectorFrom(n.key, n.value)
+}
+
+func (seq *SortedSeq) Rest() Seq {
+	if seq.stack == nil {
+		return EmptyList
+	}
+	n := seq.stack.node
+	next := n.right
+	if !seq.ascending {
+		next = n.left
+	}
+	return &SortedSeq{
+		stack:     pushSortedMapNodes(seq.stack.next, next, seq.ascending),
+		ascending: seq.ascending,
+		mode:      seq.mode,
+	}
+}
+
+func (seq *SortedSeq) IsEmpty() bool {
+	return seq.stack == nil
+}
+
+func (seq *SortedSeq) Cons(obj Object) Seq {
+	return &ConsSeq{first: obj, rest: seq}
+}
+
+func NewSortedSet(cmp Comparator) *SortedSet {
+	return &SortedSet{m: NewSortedMap(cmp)}
+}
+
+func EmptySortedSet() *SortedSet {
+	return NewSortedSet(defaultComparator{})
+}
+
+func (set *SortedSet) Add(obj Object) {
+	set.m.Set(obj, Boolean{B: true})
+}
+
+func (set *SortedSet) WithMeta(meta Map) Object {
+	res := *set
+	res.meta = SafeMerge(res.meta, meta)
+	return &res
+}
+
+func (set *SortedSet) Comparator() Comparator {
+	return set.m.cmp
+}
+
+func (set *SortedSet) EntryKey(entry Object) Object {
+	return entry
+}
+
+func (set *SortedSet) SeqFrom(key Object, ascending bool) Seq {
+	return set.m.seqFrom(key, ascending, sortedSeqKeys)
+}
+
+func (set *SortedSet) Conj(obj Object) Conjable {
+	res := *set
+	res.m = set.m.Assoc(obj, Boolean{B: true}).(*SortedMap)
+	return &res
+}
+
+func (set *SortedSet) Disjoin(key Object) Set {
+	res := *set
+	res.m = set.m.Without(key).(*SortedMap)
+	return &res
+}
+
+func (set *SortedSet) Get(key Object) (bool, Object) {
+	if n := set.m.find(key); n != nil {
+		return true, n.key
+	}
+	return false, nil
+}
+
+func (set *SortedSet) Count() int {
+	return set.m.count
+}
+
+func (set *SortedSet) Seq() Seq {
+	return set.m.Keys()
+}
+
+func (set *SortedSet) Rseq() Seq {
+	return set.m.seq(sortedSeqKeys, false)
+}
+
+func (set *SortedSet) ToString(escape bool) string {
+	var b bytes.Buffer
+	b.WriteString("#{")
+	for iter := iter(set.Seq()); iter.HasNext(); {
+		b.WriteString(iter.Next().ToString(escape))
+		if iter.HasNext() {
+			b.WriteRune(' ')
+		}
+	}
+	b.WriteRune('}')
+	return b.String()
+}
+
+func (set *SortedSet) Equals(other interface{}) bool {
+	switch otherSet := other.(type) {
+	case *SortedSet:
+		return set.m.Equals(otherSet.m)
+	case *MapSet:
+		return set.m.Equals(otherSet.m)
+	default:
+		return false
+	}
+}
+
+func (set *SortedSet) GetType() *Type {
+	return TYPE.SortedSet
+}
+
+func (set *SortedSet) Hash() uint32 {
+	return hashUnordered(set.Seq(), 2)
+}
+
+func (set *SortedSet) Call(args []Object) Object {
+	CheckArity(args, 1, 1)
+	if ok, _ := set.Get(args[0]); ok {
+		return args[0]
+	}
+	return NIL
+}
+
+func (set *SortedSet) Empty() Collection {
+	res := NewSortedSet(set.m.cmp)
+	res.meta = set.meta
+	return res
+}
+
+func (set *SortedSet) Pprint(w io.Writer, indent int) int {
+	i := indent + 1
+	fmt.Fprint(w, "#{")
+	for iter := iter(set.Seq()); iter.HasNext(); {
+		i = pprintObject(iter.Next(), indent+2, w)
+		if iter.HasNext() {
+			fmt.Fprint(w, "\n")
+			writeIndent(w, indent+2)
+		}
+	}
+	fmt.Fprint(w, "}")
+	return i + 1
+}
@@ -0,0 +1,608 @@
+package core
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+type (
+	// editToken identifies the transient that owns (and may therefore
+	// mutate in place) a given hash map or vector node. persistent!
+	// invalidates the token, after which the transient can no longer be used.
+	editToken struct {
+		persistent bool
+	}
+	Transient interface {
+		Object
+		Counted
+		Conj(obj Object) Transient
+		Persistent() Object
+	}
+	TransientVector struct {
+		edit  *editToken
+		root  *vectorNode
+		tail  []interface{}
+		count int
+		shift uint
+		meta  Map
+	}
+	TransientMap struct {
+		edit *editToken
+		// Small maps stay array maps until they outgrow HASHMAP_THRESHOLD,
+		// at which point the entries move to root.
+		array *ArrayMap
+		root  Node
+		count int
+		meta  Map
+	}
+	TransientSet struct {
+		m    *TransientMap
+		meta Map
+	}
+)
+
+func (e *editToken) ensureEditable() {
+	if e.persistent {
+		panic(RT.NewError("Transient used after persistent! call"))
+	}
+}
+
+func NewTransientVector(v *Vector) *TransientVector {
+	tail := make([]interface{}, len(v.tail), 32)
+	copy(tail, v.tail)
+	return &TransientVector{
+		edit:  &editToken{},
+		root:  v.root,
+		tail:  tail,
+		count: v.count,
+		shift: v.shift,
+		meta:  v.meta,
+	}
+}
+
+func (v *TransientVector) ToString(escape bool) string {
+	return "#object[TransientVector]"
+}
+
+func (v *TransientVector) Equals(other interface{}) bool {
+	return v == other
+}
+
+func (v *TransientVector) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (v *TransientVector) WithInfo(info *ObjectInfo) Object {
+	return v
+}
+
+func (v *TransientVector) GetType() *Type {
+	return TYPE.TransientVector
+}
+
+func (v *TransientVector) Hash() uint32 {
+	return HashPtr(uintptr(unsafe.Pointer(v)))
+}
+
+func (v *TransientVector) Count() int {
+	v.edit.ensureEditable()
+	return v.count
+}
+
+func (v *TransientVector) vector() *Vector {
+	return &Vector{count: v.count, shift: v.shift, root: v.root, tail: v.tail}
+}
+
+func (v *TransientVector) Get(key Object) (bool, Object) {
+	v.edit.ensureEditable()
+	return v.vector().Get(key)
+}
+
+func (v *TransientVector) Nth(i int) Object {
+	v.edit.ensureEditable()
+	return v.vector().at(i)
+}
+
+func (v *TransientVector) TryNth(i int, d Object) Object {
+	v.edit.ensureEditable()
+	return v.vector().TryNth(i, d)
+}
+
+func (v *TransientVector) Call(args []Object) Object {
+	CheckArity(args, 1, 1)
+	return v.Nth(assertInteger(args[0]))
+}
+
+func (v *TransientVector) Conj(obj Object) Transient {
+	v.edit.ensureEditable()
+	chargeAlloc(allocElem)
+	if v.count-v.vector().tailoff() < 32 {
+		v.tail = append(v.tail, obj)
+		v.count++
+		return v
+	}
+	// The tail is owned by v, so it becomes a node as is.
+	tailNode := &vectorNode{edit: v.edit, array: v.tail}
+	if (v.count >> 5) > (1 << v.shift) {
+		newRoot := &vectorNode{edit: v.edit, array: make([]interface{}, 32)}
+		newRoot.array[0] = v.root
+		newRoot.array[1] = newPath(v.edit, v.shift, tailNode)
+		v.root = newRoot
+		v.shift += 5
+	} else {
+		v.root = v.pushTail(v.shift, v.root, tailNode)
+	}
+	v.tail = make([]interface{}, 1, 32)
+	v.tail[0] = obj
+	v.count++
+	return v
+}
+
+// pushTail is like Vector.pushTail, but changes the nodes
+// on the path that v owns in place instead of copying them.
+func (v *TransientVector) pushTail(level uint, parent *vectorNode, tailNode *vectorNode) *vectorNode {
+	subidx := ((v.count - 1) >> level) & 0x01F
+	result := parent.ensureEditable(v.edit)
+	var nodeToInsert *vectorNode
+	if level == 5 {
+		nodeToInsert = tailNode
+	} else {
+		if parent.array[subidx] != nil {
+			nodeToInsert = v.pushTail(level-5, parent.array[subidx].(*vectorNode), tailNode)
+		} else {
+			nodeToInsert = newPath(v.edit, level-5, tailNode)
+		}
+	}
+	result.array[subidx] = nodeToInsert
+	return result
+}
+
+// doAssoc is like doAssoc, but changes the nodes
+// on the path that v owns in place instead of copying them.
+func (v *TransientVector) doAssoc(level uint, node *vectorNode, i int, val Object) *vectorNode {
+	ret := node.ensureEditable(v.edit)
+	if level == 0 {
+		ret.array[i&0x01f] = val
+	} else {
+		subidx := (i >> level) & 0x01f
+		ret.array[subidx] = v.doAssoc(level-5, node.array[subidx].(*vectorNode), i, val)
+	}
+	return ret
+}
+
+func (v *TransientVector) assocN(i int, val Object) *TransientVector {
+	v.edit.ensureEditable()
+	if i < 0 || i > v.count {
+		panic(RT.NewError((fmt.Sprintf("Index %d is out of bounds [0..%d]", i, v.count))))
+	}
+	if i == v.count {
+		return v.Conj(val).(*TransientVector)
+	}
+	if i < v.vector().tailoff() {
+		v.root = v.doAssoc(v.shift, v.root, i, val)
+	} else {
+		v.tail[i&0x01f] = val
+	}
+	return v
+}
+
+func (v *TransientVector) Assoc(key, val Object) Transient {
+	return v.assocN(assertInteger(key), val)
+}
+
+func (v *TransientVector) Pop() *TransientVector {
+	v.edit.ensureEditable()
+	if v.count == 0 {
+		panic(RT.NewError("Can't pop empty vector"))
+	}
+	cur := v.vector()
+	if v.count == 1 || v.count-cur.tailoff() > 1 {
+		v.tail = v.tail[:len(v.tail)-1]
+		v.count--
+		return v
+	}
+	res := cur.Pop().(*Vector)
+	v.root = res.root
+	v.shift = res.shift
+	v.tail = make([]interface{}, len(res.tail), 32)
+	copy(v.tail, res.tail)
+	v.count--
+	return v
+}
+
+func (v *TransientVector) Persistent() Object {
+	v.edit.ensureEditable()
+	v.edit.persistent = true
+	res := v.vector()
+	res.meta = v.meta
+	return res
+}
+
+func NewTransientMap(m Map) *TransientMap {
+	res := &TransientMap{edit: &editToken{}}
+	switch m := m.(type) {
+	case *ArrayMap:
+		res.array = m.Clone()
+		res.meta = m.meta
+	case *HashMap:
+		res.root = m.root
+		res.count = m.count
+		res.meta = m.meta
+	default:
+		panic(RT.NewError("Can't create transient from " + m.GetType().ToString(false)))
+	}
+	return res
+}
+
+func (m *TransientMap) ToString(escape bool) string {
+	return "#object[TransientMap]"
+}
+
+func (m *TransientMap) Equals(other interface{}) bool {
+	return m == other
+}
+
+func (m *TransientMap) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (m *TransientMap) WithInfo(info *ObjectInfo) Object {
+	return m
+}
+
+func (m *TransientMap) GetType() *Type {
+	return TYPE.TransientMap
+}
+
+func (m *TransientMap) Hash() uint32 {
+	return HashPtr(uintptr(unsafe.Pointer(m)))
+}
+
+func (m *TransientMap) Count() int {
+	m.edit.ensureEditable()
+	if m.array != nil {
+		return m.array.Count()
+	}
+	return m.count
+}
+
+func (m *TransientMap) Get(key Object) (bool, Object) {
+	m.edit.ensureEditable()
+	if m.array != nil {
+		return m.array.Get(key)
+	}
+	if m.root != nil {
+		if res := m.root.find(0, key.Hash(), key); res != nil {
+			return true, res.Value
+		}
+	}
+	return false, nil
+}
+
+func (m *TransientMap) Call(args []Object) Object {
+	CheckArity(args, 1, 2)
+	if ok, v := m.Get(args[0]); ok {
+		return v
+	}
+	if len(args) == 2 {
+		return args[1]
+	}
+	return NIL
+}
+
+func (m *TransientMap) Assoc(key, val Object) Transient {
+	m.edit.ensureEditable()
+	if m.array != nil {
+		if i := m.array.indexOf(key); i != -1 {
+			m.array.arr[i+1] = val
+			return m
+		}
+		if int64(len(m.array.arr)) < HASHMAP_THRESHOLD {
+			chargeAlloc(allocEntry)
+			m.array.arr = append(m.array.arr, key, val)
+			return m
+		}
+		arr := m.array.arr
+		m.array = nil
+		for i := 0; i < len(arr); i += 2 {
+			m.assocNode(arr[i], arr[i+1])
+		}
+	}
+	m.assocNode(key, val)
+	return m
+}
+
+func (m *TransientMap) assocNode(key, val Object) {
+	addedLeaf := &Box{}
+	var t Node = emptyIndexedNode
+	if m.root != nil {
+		t = m.root
+	}
+	m.root = t.assocT(m.edit, 0, key.Hash(), key, val, addedLeaf)
+	if addedLeaf.val != nil {
+		chargeAlloc(allocEntry)
+		m.count++
+	}
+}
+
+func (m *TransientMap) Conj(obj Object) Transient {
+	switch obj := obj.(type) {
+	case Vec:
+		if obj.Count() != 2 {
+			panic(RT.NewError("Vector argument to map's conj must be a vector with two elements"))
+		}
+		return m.Assoc(obj.At(0), obj.At(1))
+	case Map:
+		for iter := obj.Iter(); iter.HasNext(); {
+			p := iter.Next()
+			m.Assoc(p.Key, p.Value)
+		}
+		return m
+	default:
+		panic(RT.NewError("Argument to map's conj must be a vector with two elements or a map"))
+	}
+}
+
+func (m *TransientMap) Without(key Object) *TransientMap {
+	m.edit.ensureEditable()
+	if m.array != nil {
+		if i := m.array.indexOf(key); i != -1 {
+			last := len(m.array.arr) - 2
+			m.array.arr[i] = m.array.arr[last]
+			m.array.arr[i+1] = m.array.arr[last+1]
+			m.array.arr = m.array.arr[:last]
+		}
+		return m
+	}
+	if m.root == nil {
+		return m
+	}
+	newroot := m.root.without(0, key.Hash(), key)
+	if newroot != m.root {
+		m.root = newroot
+		m.count--
+	}
+	return m
+}
+
+func (m *TransientMap) Persistent() Object {
+	m.edit.ensureEditable()
+	m.edit.persistent = true
+	if m.array != nil {
+		m.array.meta = m.meta
+		return m.array
+	}
+	res := &HashMap{count: m.count, root: m.root}
+	res.meta = m.meta
+	return res
+}
+
+func NewTransientSet(s *MapSet) *TransientSet {
+	return &TransientSet{m: NewTransientMap(s.m), meta: s.meta}
+}
+
+func (s *TransientSet) ToString(escape bool) string {
+	return "#object[TransientSet]"
+}
+
+func (s *TransientSet) Equals(other interface{}) bool {
+	return s == other
+}
+
+func (s *TransientSet) GetInfo() *ObjectInfo {
+	return nil
+}
+
+func (s *TransientSet) WithInfo(info *ObjectInfo) Object {
+	return s
+}
+
+func (s *TransientSet) GetType() *Type {
+	return TYPE.TransientSet
+}
+
+func (s *TransientSet) Hash() uint32 {
+	return HashPtr(uintptr(unsafe.Pointer(s)))
+}
+
+func (s *TransientSet) Count() int {
+	return s.m.Count()
+}
+
+func (s *TransientSet) Get(key Object) (bool, Object) {
+	if ok, _ := s.m.Get(key); ok {
+		return true, key
+	}
+	return false, nil
+}
+
+func (s *TransientSet) Call(args []Object) Object {
+	CheckArity(args, 1, 1)
+	if ok, _ := s.Get(args[0]); ok {
+		return args[0]
+	}
+	return NIL
+}
+
+func (s *TransientSet) Conj(obj Object) Transient {
+	s.m.Assoc(obj, Boolean{B: true})
+	return s
+}
+
+func (s *TransientSet) Disjoin(key Object) *TransientSet {
+	s.m.Without(key)
+	return s
+}
+
+func (s *TransientSet) Persistent() Object {
+	res := &MapSet{m: s.m.Persistent().(Map)}
+	res.meta = s.meta
+	return res
+}
+
+func NewTransient(coll Object) Transient {
+	switch coll := coll.(type) {
+	case *Vector:
+		return NewTransientVector(coll)
+	case *ArrayVector:
+		res := NewTransientVector(NewVectorFrom(coll.arr...))
+		res.meta = coll.meta
+		return res
+	case *ArrayMap:
+		return NewTransientMap(coll)
+	case *HashMap:
+		return NewTransientMap(coll)
+	case *MapSet:
+		return NewTransientSet(coll)
+	default:
+		panic(RT.NewError("Can't create transient from " + coll.GetType().ToString(false)))
+	}
+}
+
+func (node *vectorNode) ensureEditable(edit *editToken) *vectorNode {
+	if node.edit == edit {
+		return node
+	}
+	return &vectorNode{
+		edit:  edit,
+		array: clone(node.array),
+	}
+}
+
+func (b *BitmapIndexedNode) ensureEditable(edit *editToken) *BitmapIndexedNode {
+	if b.edit == edit {
+		return b
+	}
+	return &BitmapIndexedNode{
+		edit:   edit,
+		bitmap: b.bitmap,
+		array:  clone(b.array),
+	}
+}
+
+func (b *BitmapIndexedNode) assocT(edit *editToken, shift uint, hash uint32, key Object, val Object, addedLeaf *Box) Node {
+	bit := bitpos(hash, shift)
+	idx := b.index(bit)
+	if b.bitmap&bit != 0 {
+		keyOrNull := b.array[2*idx]
+		valOrNode := b.array[2*idx+1]
+		if keyOrNull == nil {
+			n := valOrNode.(Node).assocT(edit, shift+5, hash, key, val, addedLeaf)
+			if n == valOrNode {
+				return b
+			}
+			res := b.ensureEditable(edit)
+			res.array[2*idx+1] = n
+			return res
+		}
+		if key.Equals(keyOrNull) {
+			if val == valOrNode {
+				return b
+			}
+			res := b.ensureEditable(edit)
+			res.array[2*idx+1] = val
+			return res
+		}
+		addedLeaf.val = addedLeaf
+		res := b.ensureEditable(edit)
+		res.array[2*idx] = nil
+		res.array[2*idx+1] = createNode(shift+5, keyOrNull.(Object), valOrNode.(Object), hash, key, val)
+		return res
+	}
+	n := bitCount(b.bitmap)
+	if n >= 16 {
+		nodes := make([]Node, 32)
+		jdx := mask(hash, shift)
+		nodes[jdx] = emptyIndexedNode.assocT(edit, shift+5, hash, key, val, addedLeaf)
+		j := 0
+		var i uint
+		for i = 0; i < 32; i++ {
+			if (b.bitmap>>i)&1 != 0 {
+				if b.array[j] == nil {
+					nodes[i] = b.array[j+1].(Node)
+				} else {
+					nodes[i] = emptyIndexedNode.assocT(edit, shift+5, b.array[j].(Object).Hash(), b.array[j].(Object), b.array[j+1].(Object), addedLeaf)
+				}
+				j += 2
+			}
+		}
+		return &ArrayNode{
+			edit:  edit,
+			count: n + 1,
+			array: nodes,
+		}
+	}
+	res := b.ensureEditable(edit)
+	res.array = append(res.array, nil, nil)
+	copy(res.array[2*idx+2:], res.array[2*idx:2*n])
+	res.array[2*idx] = key
+	res.array[2*idx+1] = val
+	res.bitmap |= bit
+	addedLeaf.val = addedLeaf
+	return res
+}
+
+func (n *ArrayNode) ensureEditable(edit *editToken) *ArrayNode {
+	if n.edit == edit {
+		return n
+	}
+	return &ArrayNode{
+		edit:  edit,
+		count: n.count,
+		array: append([]Node(nil), n.array...),
+	}
+}
+
+func (n *ArrayNode) assocT(edit *editToken, shift uint, hash uint32, key Object, val Object, addedLeaf *Box) Node {
+	idx := mask(hash, shift)
+	node := n.array[idx]
+	if node == nil {
+		res := n.ensureEditable(edit)
+		res.array[idx] = emptyIndexedNode.assocT(edit, shift+5, hash, key, val, addedLeaf)
+		res.count++
+		return res
+	}
+	nn := node.assocT(edit, shift+5, hash, key, val, addedLeaf)
+	if nn == node {
+		return n
+	}
+	res := n.ensureEditable(edit)
+	res.array[idx] = nn
+	return res
+}
+
+func (n *HashCollisionNode) ensureEditable(edit *editToken) *HashCollisionNode {
+	if n.edit == edit {
+		return n
+	}
+	return &HashCollisionNode{
+		edit:  edit,
+		hash:  n.hash,
+		count: n.count,
+		array: clone(n.array),
+	}
+}
+
+func (n *HashCollisionNode) assocT(edit *editToken, shift uint, hash uint32, key Object, val Object, addedLeaf *Box) Node {
+	if hash == n.hash {
+		idx := n.findIndex(key)
+		if idx != -1 {
+			if n.array[idx+1] == val {
+				return n
+			}
+			res := n.ensureEditable(edit)
+			res.array[idx+1] = val
+			return res
+		}
+		res := n.ensureEditable(edit)
+		res.array = append(res.array, key, val)
+		res.count++
+		addedLeaf.val = addedLeaf
+		return res
+	}
+	return (&BitmapIndexedNode{
+		edit:   edit,
+		bitmap: bitpos(n.hash, shift),
+		array:  []interface{}{nil, n},
+	}).assocT(edit, shift, hash, key, val, addedLeaf)
+}
@@ -82,6 +82,11 @@ func (x Time) WithInfo(info *ObjectInfo) Object {
 	return x
 }
 
+func (x UUID) WithInfo(info *ObjectInfo) Object {
+	x.info = info
+	return x
+}
+
 func (x Keyword) WithInfo(info *ObjectInfo) Object {
 	x.info = info
 	return x
@@ -161,3 +166,18 @@ func (x *VectorRSeq) WithInfo(info *ObjectInfo) Object {
 	x.info = info
 	return x
 }
+
+func (x *SortedMap) WithInfo(info *ObjectInfo) Object {
+	x.info = info
+	return x
+}
+
+func (x *SortedSet) WithInfo(info *ObjectInfo) Object {
+	x.info = info
+	return x
+}
+
+func (x *SortedSeq) WithInfo(info *ObjectInfo) Object {
+	x.info = info
+	return x
+}
@@ -24,11 +24,19 @@ type (
 	Vector struct {
 		InfoHolder
 		MetaHolder
-		root  []interface{}
+		root  *vectorNode
 		tail  []interface{}
 		count int
 		shift uint
 	}
+	// vectorNode is a node of the trie holding the elements of a Vector:
+	// 32 child nodes or, at the lowest level, 32 elements.
+	// Nodes created by a TransientVector carry its edit token,
+	// which lets it change them in place.
+	vectorNode struct {
+		edit  *editToken
+		array []interface{}
+	}
 	VectorSeq struct {
 		InfoHolder
 		MetaHolder
@@ -43,7 +51,7 @@ type (
 	}
 )
 
-var empty_node = make([]interface{}, 32)
+var empty_node = &vectorNode{array: make([]interface{}, 32)}
 
 func (v *Vector) WithMeta(meta Map) Object {
 	res := *v
@@ -70,9 +78,9 @@ func (v *Vector) arrayFor(i int) []interface{} {
 	}
 	node := v.root
 	for level := v.shift; level > 0; level -= 5 {
-		node = node[(i>>level)&0x01F].([]interface{})
+		node = node.array[(i>>level)&0x01F].(*vectorNode)
 	}
-	return node
+	return node.array
 }
 
 func (v *Vector) at(i int) Object {
@@ -90,47 +98,49 @@ func (v *Vector) At(i int) Object {
 	return v.uncheckedAt(i)
 }
 
-func newPath(level uint, node []interface{}) []interface{} {
+func newPath(edit *editToken, level uint, node *vectorNode) *vectorNode {
 	if level == 0 {
 		return node
 	}
-	result := make([]interface{}, 32)
-	result[0] = newPath(level-5, node)
+	result := &vectorNode{edit: edit, array: make([]interface{}, 32)}
+	result.array[0] = newPath(edit, level-5, node)
 	return result
 }
 
-func (v *Vector) pushTail(level uint, parent []interface{}, tailNode []interface{}) []interface{} {
+func (v *Vector) pushTail(level uint, parent *vectorNode, tailNode *vectorNode) *vectorNode {
 	subidx := ((v.count - 1) >> level) & 0x01F
-	result := clone(parent)
-	var nodeToInsert []interface{}
+	result := &vectorNode{array: clone(parent.array)}
+	var nodeToInsert *vectorNode
 	if level == 5 {
 		nodeToInsert = tailNode
 	} else {
-		if parent[subidx] != nil {
-			nodeToInsert = v.pushTail(level-5, parent[subidx].([]interface{}), tailNode)
+		if parent.array[subidx] != nil {
+			nodeToInsert = v.pushTail(level-5, parent.array[subidx].(*vectorNode), tailNode)
 		} else {
-			nodeToInsert = newPath(level-5, tailNode)
+			nodeToInsert = newPath(nil, level-5, tailNode)
 		}
 	}
-	result[subidx] = nodeToInsert
+	result.array[subidx] = nodeToInsert
 	return result
 }
 
 func (v *Vector) Conjoin(obj Object) *Vector {
+	chargeAlloc(allocElem)
 	var newTail []interface{}
 	if v.count-v.tailoff() < 32 {
 		newTail = append(clone(v.tail), obj)
 		return &Vector{count: v.count + 1, shift: v.shift, root: v.root, tail: newTail}
 	}
-	var newRoot []interface{}
+	var newRoot *vectorNode
 	newShift := v.shift
+	tailNode := &vectorNode{array: v.tail}
 	if (v.count >> 5) > (1 << v.shift) {
-		newRoot = make([]interface{}, 32)
-		newRoot[0] = v.root
-		newRoot[1] = newPath(v.shift, v.tail)
+		newRoot = &vectorNode{array: make([]interface{}, 32)}
+		newRoot.array[0] = v.root
+		newRoot.array[1] = newPath(nil, v.shift, tailNode)
 		newShift += 5
 	} else {
-		newRoot = v.pushTail(v.shift, v.root, v.tail)
+		newRoot = v.pushTail(v.shift, v.root, tailNode)
 	}
 	newTail = make([]interface{}, 1, 32)
 	newTail[0] = obj
@@ -329,22 +339,26 @@ func (v *Vector) Peek() Object {
 	return NIL
 }
 
-func (v *Vector) popTail(level uint, node []interface{}) []interface{} {
+func (v *Vector) popTail(level uint, node *vectorNode) *vectorNode {
 	subidx := ((v.count - 2) >> level) & 0x01F
 	if level > 5 {
-		newChild := v.popTail(level-5, node[subidx].([]interface{}))
+		newChild := v.popTail(level-5, node.array[subidx].(*vectorNode))
 		if newChild == nil && subidx == 0 {
 			return nil
 		} else {
-			ret := clone(node)
-			ret[subidx] = newChild
+			ret := &vectorNode{array: clone(node.array)}
+			if newChild == nil {
+				ret.array[subidx] = nil
+			} else {
+				ret.array[subidx] = newChild
+			}
 			return ret
 		}
 	} else if subidx == 0 {
 		return nil
 	} else {
-		ret := clone(node)
-		ret[subidx] = nil
+		ret := &vectorNode{array: clone(node.array)}
+		ret.array[subidx] = nil
 		return ret
 	}
 }
@@ -368,8 +382,8 @@ func (v *Vector) Pop() Stack {
 	if newRoot == nil {
 		newRoot = empty_node
 	}
-	if v.shift > 5 && newRoot[1] == nil {
-		newRoot = newRoot[0].([]interface{})
+	if v.shift > 5 && newRoot.array[1] == nil {
+		newRoot = newRoot.array[0].(*vectorNode)
 		newShift -= 5
 	}
 	res := &Vector{count: v.count - 1, shift: newShift, root: newRoot, tail: newTail}
@@ -389,13 +403,13 @@ func (v *Vector) EntryAt(key Object) *ArrayVector {
 	return nil
 }
 
-func doAssoc(level uint, node []interface{}, i int, val Object) []interface{} {
-	ret := clone(node)
+func doAssoc(level uint, node *vectorNode, i int, val Object) *vectorNode {
+	ret := &vectorNode{array: clone(node.array)}
 	if level == 0 {
-		ret[i&0x01f] = val
+		ret.array[i&0x01f] = val
 	} else {
 		subidx := (i >> level) & 0x01f
-		ret[subidx] = doAssoc(level-5, node[subidx].([]interface{}), i, val)
+		ret.array[subidx] = doAssoc(level-5, node.array[subidx].(*vectorNode), i, val)
 	}
 	return ret
 }
@@ -462,22 +476,18 @@ func NewVectorFrom(objs ...Object) *Vector {
 		return EmptyVector()
 	}
 	if n <= 32 {
+		chargeAlloc(n * allocElem)
 		tail := make([]interface{}, n)
 		for i, o := range objs {
 			tail[i] = o
 		}
 		return &Vector{count: n, shift: 5, root: empty_node, tail: tail}
 	}
-	// First 32 in one tail, then Conjoin the rest.
-	tail := make([]interface{}, 32)
-	for i := 0; i < 32; i++ {
-		tail[i] = objs[i]
-	}
-	res := &Vector{count: 32, shift: 5, root: empty_node, tail: tail}
-	for i := 32; i < n; i++ {
-		res = res.Conjoin(objs[i])
+	res := NewTransientVector(EmptyVector())
+	for _, o := range objs {
+		res.Conj(o)
 	}
-	return res
+	return res.Persistent().(*Vector)
 }
 
 func NewVectorFromSeq(seq Seq) *Vector {
@@ -493,12 +503,12 @@ func NewVectorFromSeq(seq Seq) *Vector {
 		}
 		return NewVectorFrom(objs...)
 	}
-	res := EmptyVector()
+	res := NewTransientVector(EmptyVector())
 	for !seq.IsEmpty() {
-		res = res.Conjoin(seq.First())
+		res.Conj(seq.First())
 		seq = seq.Rest()
 	}
-	return res
+	return res.Persistent().(*Vector)
 }
 
 func (v *Vector) Empty() Collection {
@@ -1,3 +1,8 @@
 #!/usr/bin/env bash
 
+# The linter keeps its index in ~/.jokerd/cache,
+# so run the tests with a home directory of their own.
+export HOME=$(mktemp -d)
+trap 'rm -rf "$HOME"' EXIT
+
 ./joker tests/run-flag-tests.joke
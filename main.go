@@ -8,17 +8,21 @@ import (
 	"math"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	. "github.com/candid82/joker/core"
 	_ "github.com/candid82/joker/std/base64"
 	_ "github.com/candid82/joker/std/bolt"
 	_ "github.com/candid82/joker/std/crypto"
 	_ "github.com/candid82/joker/std/csv"
+	_ "github.com/candid82/joker/std/edn"
 	_ "github.com/candid82/joker/std/filepath"
 	_ "github.com/candid82/joker/std/git"
 	_ "github.com/candid82/joker/std/hex"
@@ -186,7 +190,7 @@ func processReplCommand(reader *Reader, phase Phase, parseContext *ParseContext,
 		return false
 	}
 
-	res := Eval(expr, nil)
+	res := EvalWithTimeout(expr, nil)
 	replContext.PushValue(res)
 	PrintObject(res, Stdout)
 	fmt.Fprintln(Stdout, "")
@@ -300,9 +304,21 @@ func lintFile(filename string, dialect Dialect, workingDir string) {
 	}
 	ReadConfig(filename, workingDir)
 	configureLinterMode(dialect, filename, workingDir)
-	if processFile(filename, phase) == nil {
+	// The project's index is only used here: it's saved by lintDir.
+	LoadLintIndex(filename)
+	err := processFile(filename, phase)
+	IndexLintedFile(filename, err)
+	if err == nil {
 		WarnOnUnusedNamespaces()
 		WarnOnUnusedVars()
+		CheckLintReferences()
+		WarnOnUnusedSuppressions()
+	}
+}
+
+func saveLintIndex() {
+	if err := SaveLintIndex(); err != nil && VerbosityLevel > 0 {
+		fmt.Fprintf(Stderr, "Not saving lint index: %s\n", err)
 	}
 }
 
@@ -331,35 +347,169 @@ func isIgnored(path string) bool {
 	return false
 }
 
-func lintDir(dirname string, dialect Dialect, reportGloballyUnused bool) {
-	var processErr error
-	phase := PARSE
-	if dialect == EDN {
-		phase = READ
-	}
-	ns := GLOBAL_ENV.CurrentNamespace()
-	ReadConfig("", dirname)
-	configureLinterMode(dialect, "", dirname)
+// lintDirFiles returns the files in dirname to lint, in lexical order.
+func lintDirFiles(dirname string, dialect Dialect) []string {
+	var files []string
 	filepath.Walk(dirname, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			fmt.Fprintln(Stderr, "Error: ", err)
 			return nil
 		}
 		if !info.IsDir() && matchesDialect(path, dialect) && !isIgnored(path) {
-			GLOBAL_ENV.CoreNamespace.Resolve("*loaded-libs*").Value = EmptySet()
-			processErr = processFile(path, phase)
-			if processErr == nil {
-				WarnOnUnusedNamespaces()
-				WarnOnUnusedVars()
-			}
-			ResetUsage()
-			GLOBAL_ENV.SetCurrentNamespace(ns)
+			files = append(files, path)
 		}
 		return nil
 	})
-	if processErr == nil && reportGloballyUnused {
-		WarnOnGloballyUnusedNamespaces()
-		WarnOnGloballyUnusedVars()
+	return files
+}
+
+// lintFiles lints files in order, collecting what is found for
+// TakeLintResult. It returns the files that failed to be processed.
+// Each file is linted in the environment it would be linted in on its
+// own, so that what is found doesn't depend on the other files.
+func lintFiles(files []string, dialect Dialect) []string {
+	var failed []string
+	phase := PARSE
+	if dialect == EDN {
+		phase = READ
+	}
+	ns := GLOBAL_ENV.CurrentNamespace()
+	env := SaveLintEnv()
+	LINT_COLLECT = true
+	defer func() { LINT_COLLECT = false }()
+	for _, path := range files {
+		GLOBAL_ENV.CoreNamespace.Resolve("*loaded-libs*").Value = EmptySet()
+		processErr := processFile(path, phase)
+		IndexLintedFile(path, processErr)
+		if processErr == nil {
+			WarnOnUnusedNamespaces()
+			WarnOnUnusedVars()
+		} else {
+			failed = append(failed, path)
+		}
+		CollectLintFileUsage()
+		ResetUsage()
+		env.Restore()
+		GLOBAL_ENV.SetCurrentNamespace(ns)
+	}
+	return failed
+}
+
+// lintDir lints the files in dirname. Unless jobs is 1, they are split
+// between up to jobs worker processes, each run by a goroutine, that lint
+// their share of files in their own environment (see lintWorker).
+// Workers are processes rather than goroutines because the reader and
+// the linter keep their state in globals. If they can't be run or any
+// of them fails, the files are linted by this process instead.
+// Problems are printed sorted by file and position either way.
+func lintDir(dirname string, dialect Dialect, reportGloballyUnused bool, jobs int) {
+	ReadConfig("", dirname)
+	configureLinterMode(dialect, "", dirname)
+	NewLintIndex(dirname)
+	files := lintDirFiles(dirname, dialect)
+	var results []*LintResult
+	if jobs > 1 && len(files) > 1 {
+		var err error
+		if results, err = runLintWorkers(files, dirname, dialect, jobs); err != nil {
+			fmt.Fprintf(Stderr, "Warning: linting in a single process: %s\n", err)
+		}
+	}
+	if results == nil {
+		results = []*LintResult{TakeLintResult(lintFiles(files, dialect))}
+	}
+	LINT_SORT = true
+	failed := make(map[string]bool)
+	for _, r := range results {
+		MergeLintResult(r)
+		for _, f := range r.Failed {
+			failed[f] = true
+		}
+	}
+	// As when linting files one by one, the checks that need all of
+	// them are only skipped if the last one failed to be processed.
+	if len(files) == 0 || !failed[files[len(files)-1]] {
+		CheckLintReferences()
+		if reportGloballyUnused {
+			WarnOnGloballyUnusedNamespaces()
+			WarnOnGloballyUnusedVars()
+		}
+		WarnOnUnusedSuppressions()
+	}
+	SortLintProblems()
+	saveLintIndex()
+}
+
+// runLintWorkers lints files with up to jobs worker processes,
+// giving each one a contiguous share of files, and returns
+// their results in the order of the shares. What workers print to
+// stderr is only written if all of them succeed. Workers run
+// this executable, or the one given with --lint-worker-exe.
+func runLintWorkers(files []string, dirname string, dialect Dialect, jobs int) ([]*LintResult, error) {
+	exe := lintWorkerExe
+	if exe == "" {
+		var err error
+		if exe, err = os.Executable(); err != nil {
+			return nil, err
+		}
+	}
+	if jobs > len(files) {
+		jobs = len(files)
+	}
+	results := make([]*LintResult, jobs)
+	outputs := make([][]byte, jobs)
+	errs := make([]error, jobs)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func(i int, share []string) {
+			defer wg.Done()
+			results[i], outputs[i], errs[i] = runLintWorker(exe, share, dirname, dialect)
+		}(i, files[i*len(files)/jobs:(i+1)*len(files)/jobs])
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, output := range outputs {
+		Stderr.Write(output)
+	}
+	return results, nil
+}
+
+// runLintWorker runs a worker process linting files (see lintWorker),
+// returning its result and what it printed to stderr.
+func runLintWorker(exe string, files []string, dirname string, dialect Dialect) (*LintResult, []byte, error) {
+	cmd := exec.Command(exe, "--lint-worker", "--dialect", dialectArg(dialect), "--working-dir", dirname)
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\n"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, stderr.Bytes(), fmt.Errorf("lint worker: %s", err)
+	}
+	res, err := ReadLintResult(stdout.Bytes())
+	return res, stderr.Bytes(), err
+}
+
+// lintWorker lints the files of dirname listed on stdin, one per line,
+// for the process running lintDir, and writes its result to stdout.
+func lintWorker(dirname string, dialect Dialect) {
+	LINT_WORKER = true
+	ReadConfig("", dirname)
+	configureLinterMode(dialect, "", dirname)
+	NewLintIndex(dirname)
+	data, err := io.ReadAll(Stdin)
+	PanicOnErr(err)
+	var files []string
+	if len(data) > 0 {
+		files = strings.Split(string(data), "\n")
+	}
+	failed := lintFiles(files, dialect)
+	if err := WriteLintResult(Stdout, TakeLintResult(failed)); err != nil {
+		fmt.Fprintln(Stderr, "Error: ", err)
+		ExitJoker(1)
 	}
 }
 
@@ -377,6 +527,18 @@ func dialectFromArg(arg string) Dialect {
 	return UNKNOWN
 }
 
+func dialectArg(dialect Dialect) string {
+	switch dialect {
+	case CLJS:
+		return "cljs"
+	case JOKER:
+		return "joker"
+	case EDN:
+		return "edn"
+	}
+	return "clj"
+}
+
 func usage(out io.Writer) {
 	fmt.Fprintf(out, "Joker - %s\n\n", VERSION)
 	fmt.Fprintln(out, "Usage: joker [args] [-- <repl-args>]                starts a repl")
@@ -424,6 +586,24 @@ func usage(out io.Writer) {
 	fmt.Fprintln(out, "  --dialect <dialect>")
 	fmt.Fprintln(out, "    Set input dialect (\"clj\", \"cljs\", \"joker\", \"edn\") for linting;")
 	fmt.Fprintln(out, "    default is inferred from <filename> suffix, if any.")
+	fmt.Fprintln(out, "  --lint-format <format>")
+	fmt.Fprintln(out, "    Report problems found by the linter as \"text\" (default, to standard error),")
+	fmt.Fprintln(out, "    or as \"json\" or \"sarif\" (to standard output, once linting is done).")
+	fmt.Fprintln(out, "  --lint-jobs <n>")
+	fmt.Fprintln(out, "    Lint directories with up to <n> worker processes (default: number of CPUs);")
+	fmt.Fprintln(out, "    problems are reported sorted by file and position either way.")
+	fmt.Fprintln(out, "  --compile")
+	fmt.Fprintln(out, "    Compile functions and top-level forms to Go closures before evaluating them")
+	fmt.Fprintln(out, "    (faster for CPU-bound code).")
+	fmt.Fprintln(out, "  --no-lib-cache")
+	fmt.Fprintln(out, "    Don't use or update the cache of libs loaded via *classpath* (~/.jokerd/cache).")
+	fmt.Fprintln(out, "  --sandbox")
+	fmt.Fprintln(out, "    Deny access to joker.os, joker.http and other namespaces, and to files accessed")
+	fmt.Fprintln(out, "    via slurp, spit, load-file and require, except as allowed by :sandbox in .joker")
+	fmt.Fprintln(out, "    (found starting from the current directory).")
+	fmt.Fprintln(out, "  --eval-timeout <ms>")
+	fmt.Fprintln(out, "    Throw LimitExceeded if evaluating <filename> or <expr> (or a form entered")
+	fmt.Fprintln(out, "    in the REPL) takes longer than <ms> milliseconds.")
 	fmt.Fprintln(out, "  --hashmap-threshold <n>")
 	fmt.Fprintln(out, "    Set HASHMAP_THRESHOLD accordingly (internal magic of some sort).")
 	fmt.Fprintln(out, "  --profiler <type>")
@@ -448,6 +628,10 @@ var (
 	workingDir               string
 	lintFlag                 bool
 	reportGloballyUnusedFlag bool
+	lintJobs                 int = runtime.NumCPU()
+	lintJobsFlag             bool
+	lintWorkerFlag           bool
+	lintWorkerExe            string
 	dialect                  Dialect = UNKNOWN
 	eval                     string
 	replFlag                 bool
@@ -465,6 +649,7 @@ var (
 	exitToRepl               bool
 	errorToRepl              bool
 	writeFlag                bool
+	sandboxFlag              bool
 )
 
 func isNumber(s string) bool {
@@ -568,6 +753,40 @@ func parseArgs(args []string) {
 		case "--lintedn":
 			lintFlag = true
 			dialect = EDN
+		case "--lint-format":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				if !IsValidLintFormat(args[i]) {
+					fmt.Fprintf(Stderr, "Error: --lint-format must be \"text\", \"json\" or \"sarif\", got `%s'.\n", args[i])
+					ExitJoker(21)
+				}
+				LINT_FORMAT = args[i]
+			} else {
+				missing = true
+			}
+		case "--lint-jobs":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				jobs, err := strconv.ParseInt(args[i], 10, 32)
+				if err != nil || jobs <= 0 {
+					fmt.Fprintf(Stderr, "Error: --lint-jobs requires a positive number, got `%s'.\n", args[i])
+					ExitJoker(23)
+				}
+				lintJobs = int(jobs)
+				lintJobsFlag = true
+			} else {
+				missing = true
+			}
+		case "--lint-worker": // internal, see lintWorker
+			lintFlag = true
+			lintWorkerFlag = true
+		case "--lint-worker-exe": // internal, for testing the fallback of runLintWorkers
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				lintWorkerExe = args[i]
+			} else {
+				missing = true
+			}
 		case "--dialect":
 			if i < length-1 && notOption(args[i+1]) {
 				i += 1 // shift
@@ -591,6 +810,18 @@ func parseArgs(args []string) {
 			} else {
 				missing = true
 			}
+		case "--eval-timeout":
+			if i < length-1 && notOption(args[i+1]) {
+				i += 1 // shift
+				ms, err := strconv.ParseInt(args[i], 10, 64)
+				if err != nil || ms <= 0 {
+					fmt.Fprintf(Stderr, "Error: --eval-timeout requires a positive number of milliseconds, got `%s'.\n", args[i])
+					ExitJoker(18)
+				}
+				EVAL_TIMEOUT = time.Duration(ms) * time.Millisecond
+			} else {
+				missing = true
+			}
 		case "-e", "--eval":
 			if i < length-1 && notOption(args[i+1]) {
 				i += 1 // shift
@@ -612,6 +843,12 @@ func parseArgs(args []string) {
 			} else {
 				missing = true
 			}
+		case "--compile":
+			COMPILE_MODE = true
+		case "--no-lib-cache":
+			LIB_CACHE = false
+		case "--sandbox":
+			sandboxFlag = true
 		case "--no-readline":
 			noReadline = true
 		case "--no-repl-history":
@@ -726,7 +963,7 @@ func main() {
 
 	saveForRepl = saveForRepl && (exitToRepl || errorToRepl) // don't bother saving stuff if no repl
 
-	RT.GIL.Lock()
+	RT.AcquireGIL()
 	ProcessCoreData()
 
 	GLOBAL_ENV.ReferCoreToUser()
@@ -741,6 +978,8 @@ func main() {
 		fmt.Fprintf(debugOut, "lintFlag=%v\n", lintFlag)
 		fmt.Fprintf(debugOut, "reportGloballyUnusedFlag=%v\n", reportGloballyUnusedFlag)
 		fmt.Fprintf(debugOut, "dialect=%v\n", dialect)
+		fmt.Fprintf(debugOut, "LINT_FORMAT=%v\n", LINT_FORMAT)
+		fmt.Fprintf(debugOut, "lintJobs=%v\n", lintJobs)
 		fmt.Fprintf(debugOut, "workingDir=%v\n", workingDir)
 		fmt.Fprintf(debugOut, "HASHMAP_THRESHOLD=%v\n", HASHMAP_THRESHOLD)
 		fmt.Fprintf(debugOut, "eval=%v\n", eval)
@@ -754,6 +993,7 @@ func main() {
 		fmt.Fprintf(debugOut, "exitToRepl=%v\n", exitToRepl)
 		fmt.Fprintf(debugOut, "errorToRepl=%v\n", errorToRepl)
 		fmt.Fprintf(debugOut, "saveForRepl=%v\n", saveForRepl)
+		fmt.Fprintf(debugOut, "sandboxFlag=%v\n", sandboxFlag)
 	}
 
 	if helpFlag {
@@ -809,6 +1049,17 @@ func main() {
 		defer finish()
 	}
 
+	if sandboxFlag {
+		if lintFlag {
+			fmt.Fprintf(Stderr, "Error: Cannot combine --sandbox and --lint.\n")
+			ExitJoker(19)
+		}
+		if err := EnableSandbox(); err != nil {
+			fmt.Fprintln(Stderr, err)
+			ExitJoker(20)
+		}
+	}
+
 	if eval != "" {
 		if lintFlag {
 			fmt.Fprintf(Stderr, "Error: Cannot combine --eval/-e and --lint.\n")
@@ -861,16 +1112,23 @@ func main() {
 		if dialect == UNKNOWN {
 			dialect = detectDialect(filename)
 		}
+		if lintWorkerFlag {
+			lintWorker(workingDir, dialect)
+			return
+		}
 		if filename != "" {
 			lintFile(filename, dialect, workingDir)
 		} else if workingDir != "" {
-			lintDir(workingDir, dialect, reportGloballyUnusedFlag)
+			lintDir(workingDir, dialect, reportGloballyUnusedFlag, lintJobs)
 		} else {
 			fmt.Fprintf(Stderr, "Error: Missing --file or --working-dir argument.\n")
 			ExitJoker(16)
 		}
-		if PROBLEM_COUNT > 0 {
-			ExitJoker(1)
+		if err := PrintLintProblems(Stdout); err != nil {
+			fmt.Fprintln(Stderr, "Error: ", err)
+		}
+		if code := LintExitCode(); code != 0 {
+			ExitJoker(code)
 		}
 		return
 	}
@@ -880,6 +1138,16 @@ func main() {
 		ExitJoker(11)
 	}
 
+	if LINT_FORMAT != "text" {
+		fmt.Fprintf(Stderr, "Error: Cannot specify --lint-format option when not linting.\n")
+		ExitJoker(22)
+	}
+
+	if lintJobsFlag {
+		fmt.Fprintf(Stderr, "Error: Cannot specify --lint-jobs option when not linting.\n")
+		ExitJoker(24)
+	}
+
 	if filename != "" {
 		if err := processFile(filename, phase); err != nil {
 			if !errorToRepl {
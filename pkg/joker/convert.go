@@ -0,0 +1,214 @@
+package joker
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/candid82/joker/core"
+)
+
+// ToJoker converts a Go value to a Joker value:
+//
+//	nil, pointers to nil               nil
+//	bool                               Boolean
+//	signed and unsigned integers       Int (BigInt if it doesn't fit)
+//	float32, float64                   Double
+//	string                             String
+//	*big.Int, *big.Float               BigInt, BigFloat
+//	time.Time                          Time
+//	slices and arrays                  Vector
+//	maps                               Map (keys and values converted)
+//	func([]Object) Object              function
+//	other funcs                        function (see Func)
+//	Object                             itself
+//
+// Pointers are dereferenced. Other values (e.g. structs) can't be converted.
+func ToJoker(v interface{}) (Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return core.NIL, nil
+	case Object:
+		return v, nil
+	case bool:
+		return core.MakeBoolean(v), nil
+	case string:
+		return core.MakeString(v), nil
+	case *big.Int:
+		return core.MakeBigInt(new(big.Int).Set(v)), nil
+	case *big.Float:
+		return core.MakeBigFloat(new(big.Float).Copy(v)), nil
+	case time.Time:
+		return core.MakeTime(v), nil
+	case func([]Object) Object:
+		return core.Proc{Fn: v, Name: "go-func"}, nil
+	case core.ProcFn:
+		return core.Proc{Fn: v, Name: "go-func"}, nil
+	}
+	return toJoker(reflect.ValueOf(v))
+}
+
+func toJoker(v reflect.Value) (Object, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return core.MakeInt(int(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if int(u) < 0 || uint64(int(u)) != u {
+			return core.MakeBigInt(new(big.Int).SetUint64(u)), nil
+		}
+		return core.MakeInt(int(u)), nil
+	case reflect.Float32, reflect.Float64:
+		return core.MakeDouble(v.Float()), nil
+	case reflect.Bool:
+		return core.MakeBoolean(v.Bool()), nil
+	case reflect.String:
+		return core.MakeString(v.String()), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return core.NIL, nil
+		}
+		return ToJoker(v.Elem().Interface())
+	case reflect.Func:
+		if v.IsNil() {
+			return core.NIL, nil
+		}
+		return Func("go-func", v.Interface())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return core.NIL, nil
+		}
+		objs := make([]Object, v.Len())
+		for i := range objs {
+			obj, err := ToJoker(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			objs[i] = obj
+		}
+		return core.NewVectorFrom(objs...), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return core.NIL, nil
+		}
+		keyvals := make([]Object, 0, 2*v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key, err := ToJoker(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			value, err := ToJoker(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			keyvals = append(keyvals, key, value)
+		}
+		if int64(len(keyvals)) > 2*core.HASHMAP_THRESHOLD {
+			return core.NewHashMap(keyvals...), nil
+		}
+		res := core.EmptyArrayMap()
+		for i := 0; i < len(keyvals); i += 2 {
+			res.Add(keyvals[i], keyvals[i+1])
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("can't convert %s to a Joker value", v.Type())
+}
+
+// ToGo converts a Joker value to a Go value:
+//
+//	nil                                nil
+//	Boolean, Int, Double, String       bool, int, float64, string
+//	Char, Time                         rune, time.Time
+//	BigInt, BigFloat, Ratio            *big.Int, *big.Float, *big.Rat
+//	Keyword, Symbol                    string (without the colon)
+//	vectors, lists, seqs and sets      []interface{}
+//	maps                               map[string]interface{} if all keys are
+//	                                   strings, keywords or symbols,
+//	                                   map[interface{}]interface{} otherwise
+//
+// Other values (e.g. functions) are returned as is.
+// Seqs must be finite. Realizing lazy seqs runs Joker code, which
+// requires the GIL: outside of Go functions called by Joker code,
+// use Interpreter.ToGo instead.
+func ToGo(obj Object) (interface{}, error) {
+	switch obj := obj.(type) {
+	case core.Nil:
+		return nil, nil
+	case core.Native:
+		return obj.Native(), nil
+	case *core.BigInt:
+		return obj.BigInt(), nil
+	case *core.BigFloat:
+		return obj.BigFloat(), nil
+	case *core.Ratio:
+		return obj.Ratio(), nil
+	case core.Keyword:
+		return qualifiedName(obj.Namespace(), obj.Name()), nil
+	case core.Symbol:
+		return qualifiedName(obj.Namespace(), obj.Name()), nil
+	case core.Map:
+		return mapToGo(obj)
+	case core.Seqable:
+		switch obj.(type) {
+		case core.Vec, core.Seq, core.Set:
+			var res []interface{}
+			for s := obj.Seq(); !s.IsEmpty(); s = s.Rest() {
+				v, err := ToGo(s.First())
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, v)
+			}
+			if res == nil {
+				res = []interface{}{}
+			}
+			return res, nil
+		}
+	}
+	return obj, nil
+}
+
+func qualifiedName(ns, name string) string {
+	if ns == "" {
+		return name
+	}
+	return ns + "/" + name
+}
+
+func mapToGo(m core.Map) (interface{}, error) {
+	stringKeys := true
+	for iter := m.Iter(); iter.HasNext(); {
+		switch iter.Next().Key.(type) {
+		case core.String, core.Keyword, core.Symbol:
+		default:
+			stringKeys = false
+		}
+	}
+	res := make(map[interface{}]interface{}, m.Count())
+	byString := make(map[string]interface{}, m.Count())
+	for iter := m.Iter(); iter.HasNext(); {
+		p := iter.Next()
+		k, err := ToGo(p.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := ToGo(p.Value)
+		if err != nil {
+			return nil, err
+		}
+		if stringKeys {
+			byString[k.(string)] = v
+			continue
+		}
+		if k != nil && !reflect.TypeOf(k).Comparable() {
+			return nil, fmt.Errorf("can't convert map key %s to a Go map key", p.Key.ToString(true))
+		}
+		res[k] = v
+	}
+	if stringKeys {
+		return byString, nil
+	}
+	return res, nil
+}
@@ -0,0 +1,147 @@
+package joker
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToJoker(t *testing.T) {
+	var nilPtr *int
+	n := 5
+	tests := []struct {
+		v        interface{}
+		expected string
+	}{
+		{nil, "nil"},
+		{nilPtr, "nil"},
+		{&n, "5"},
+		{true, "true"},
+		{-3, "-3"},
+		{int8(-8), "-8"},
+		{uint16(16), "16"},
+		{uint64(math.MaxUint64), "18446744073709551615N"},
+		{1.5, "1.5"},
+		{float32(0.5), "0.5"},
+		{"s", `"s"`},
+		{big.NewInt(7), "7N"},
+		{[]string{"a", "b"}, `["a" "b"]`},
+		{[2]int{1, 2}, "[1 2]"},
+		{[]interface{}{1, "a", nil, []int{2}}, `[1 "a" nil [2]]`},
+		{[]int(nil), "nil"},
+		{map[string]int{"a": 1}, `{"a" 1}`},
+		{map[int][]bool{1: {true}}, "{1 [true]}"},
+	}
+	for _, test := range tests {
+		res, err := ToJoker(test.v)
+		if err != nil {
+			t.Errorf("%#v: %s", test.v, err)
+			continue
+		}
+		if s := res.ToString(true); s != test.expected {
+			t.Errorf("%#v: expected %s, got %s", test.v, test.expected, s)
+		}
+	}
+	for _, v := range []interface{}{struct{}{}, make(chan int), []struct{}{{}}, map[string]struct{}{"a": {}}} {
+		if _, err := ToJoker(v); err == nil {
+			t.Errorf("%#v: expected an error", v)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	in := NewInterpreter(Options{})
+	tests := []struct {
+		code     string
+		expected interface{}
+	}{
+		{"nil", nil},
+		{"true", true},
+		{"42", 42},
+		{"1.5", 1.5},
+		{`"s"`, "s"},
+		{`\a`, 'a'},
+		{":kw", "kw"},
+		{":ns/kw", "ns/kw"},
+		{"'sym", "sym"},
+		{"10000000000000000000000N", new(big.Int).Exp(big.NewInt(10), big.NewInt(22), nil)},
+		{"1/3", big.NewRat(1, 3)},
+		{"[1 [2]]", []interface{}{1, []interface{}{2}}},
+		{"'(1 2)", []interface{}{1, 2}},
+		{"(map inc [1 2])", []interface{}{2, 3}},
+		{"[]", []interface{}{}},
+		{"#{1}", []interface{}{1}},
+		{`{:a 1 "b" [2]}`, map[string]interface{}{"a": 1, "b": []interface{}{2}}},
+		{"{1 :a}", map[interface{}]interface{}{1: "a"}},
+	}
+	for _, test := range tests {
+		res, err := in.Eval(test.code)
+		if err != nil {
+			t.Fatalf("%s: %s", test.code, err)
+		}
+		v, err := in.ToGo(res)
+		if err != nil {
+			t.Errorf("%s: %s", test.code, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("%s: expected %#v, got %#v", test.code, test.expected, v)
+		}
+	}
+	res, err := in.Eval("{[1] :a}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.ToGo(res); err == nil {
+		t.Error("expected an error converting a map with vector keys")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := NewInterpreter(Options{})
+	now := time.Now()
+	values := []interface{}{
+		nil,
+		true,
+		-7,
+		2.25,
+		"str",
+		now,
+		big.NewInt(-12),
+		[]interface{}{1, "a", []interface{}{false}},
+		map[string]interface{}{"a": 1, "b": []interface{}{"c"}},
+		map[interface{}]interface{}{1: "one", 2.5: nil},
+	}
+	for _, v := range values {
+		obj, err := ToJoker(v)
+		if err != nil {
+			t.Errorf("%#v: %s", v, err)
+			continue
+		}
+		res, err := in.ToGo(obj)
+		if err != nil {
+			t.Errorf("%#v: %s", v, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, v) {
+			t.Errorf("expected %#v, got %#v", v, res)
+		}
+	}
+	// Values also survive a trip through Joker code.
+	if err := in.SetVar("data", map[string]interface{}{"xs": []int{1, 2, 3}}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := in.Eval(`(update data "xs" #(map inc %))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := in.ToGo(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]interface{}{"xs": []interface{}{2, 3, 4}}; !reflect.DeepEqual(v, expected) {
+		t.Errorf("expected %#v, got %#v", expected, v)
+	}
+}
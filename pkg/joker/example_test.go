@@ -0,0 +1,39 @@
+package joker_test
+
+import (
+	"fmt"
+
+	"github.com/candid82/joker/pkg/joker"
+)
+
+func Example() {
+	in := joker.NewInterpreter(joker.Options{})
+	defer in.Close()
+	if err := in.SetVar("limit", 10); err != nil {
+		panic(err)
+	}
+	res, err := in.Eval(`(filter #(< % limit) [5 15 7])`)
+	if err != nil {
+		panic(err)
+	}
+	v, err := in.ToGo(res)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%#v\n", v)
+	// Output: []interface {}{5, 7}
+}
+func ExampleFunc() {
+	in := joker.NewInterpreter(joker.Options{Namespace: "example"})
+	defer in.Close()
+	if err := in.SetVar("div", func(a, b int) int { return a / b }); err != nil {
+		panic(err)
+	}
+	res, err := in.Eval("(div 7 2)")
+	fmt.Println(res.ToString(false), err)
+	_, err = in.Eval("(div 1 0)")
+	fmt.Println(err)
+	// Output:
+	// 3 <nil>
+	// <eval>:1:1: Eval error: example/div: runtime error: integer divide by zero
+}
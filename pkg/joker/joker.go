@@ -0,0 +1,358 @@
+// Package joker runs Joker code inside Go programs.
+//
+//	in := joker.NewInterpreter(joker.Options{})
+//	defer in.Close()
+//	in.SetVar("limit", 10)
+//	res, err := in.Eval(`(filter #(< % limit) [5 15 7])`)
+//	v, err := in.ToGo(res) // []interface{}{5, 7}
+//
+// Interpreters can be created and used from any goroutine.
+// Like goroutines started by Joker code, they share the global
+// interpreter lock: only one of them runs Joker code at any given time.
+// Each interpreter has its own namespace for its code and vars,
+// and its own *in*, *out*, *err*, *command-line-args* and *classpath*.
+// Namespaces an interpreter creates with ns, in-ns or create-ns also
+// belong to it. Interpreters can read vars of any namespace, but only
+// change namespaces that belong to them: defining, interning and
+// setting vars, changing their metadata, mappings and aliases, and
+// switching *ns* to them fail with an error in other namespaces.
+// Those include joker.core and libraries, which are loaded once per
+// process and shared by all interpreters. Namespaces created by loading
+// a lib from *classpath* are shared once loaded, so interpreters can't
+// reload them. Values held by shared vars, such as atoms, are not
+// protected. Close removes the namespaces of the interpreter.
+package joker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/candid82/joker/core"
+	_ "github.com/candid82/joker/std/base64"
+	_ "github.com/candid82/joker/std/bolt"
+	_ "github.com/candid82/joker/std/crypto"
+	_ "github.com/candid82/joker/std/csv"
+	_ "github.com/candid82/joker/std/edn"
+	_ "github.com/candid82/joker/std/filepath"
+	_ "github.com/candid82/joker/std/git"
+	_ "github.com/candid82/joker/std/hex"
+	_ "github.com/candid82/joker/std/html"
+	_ "github.com/candid82/joker/std/http"
+	_ "github.com/candid82/joker/std/io"
+	_ "github.com/candid82/joker/std/json"
+	_ "github.com/candid82/joker/std/markdown"
+	_ "github.com/candid82/joker/std/math"
+	_ "github.com/candid82/joker/std/os"
+	_ "github.com/candid82/joker/std/runtime"
+	_ "github.com/candid82/joker/std/strconv"
+	_ "github.com/candid82/joker/std/string"
+	_ "github.com/candid82/joker/std/time"
+	_ "github.com/candid82/joker/std/url"
+	_ "github.com/candid82/joker/std/uuid"
+	_ "github.com/candid82/joker/std/yaml"
+)
+
+// Object is a Joker value.
+type Object = core.Object
+
+var errClosed = errors.New("joker: interpreter is closed")
+
+type (
+	// Options configure an Interpreter. The zero value is
+	// an interpreter using the standard streams of the process,
+	// with no limits.
+	Options struct {
+		// *in*, *out* and *err*. Default to os.Stdin,
+		// os.Stdout and os.Stderr.
+		Stdin  io.Reader
+		Stdout io.Writer
+		Stderr io.Writer
+		// *command-line-args*.
+		Args []string
+		// *classpath*, used to find libs, as a list of directories
+		// separated by os.PathListSeparator (see --classpath).
+		ClassPath string
+		// Namespace the code runs in. Defaults to a new
+		// namespace unique to the interpreter. It must not exist:
+		// the namespaces of interpreters are removed when they're closed.
+		Namespace string
+		// Limits on each call of Eval, LoadFile and Call
+		// (see with-limits*). Zero means no limit.
+		// MaxAlloc is in bytes.
+		Timeout  time.Duration
+		MaxSteps int
+		MaxAlloc int
+	}
+
+	// Interpreter evaluates Joker code.
+	Interpreter struct {
+		opts Options
+		// Serializes use of rt, which has the interpreter's bindings
+		// of *ns*, *out* etc. as thread bindings.
+		mu     sync.Mutex
+		rt     *core.Runtime
+		ns     *core.Namespace
+		closed bool
+	}
+)
+
+var (
+	initOnce         sync.Once
+	interpreterCount int64
+)
+
+func initCore() {
+	rt := core.NewRuntime()
+	rt.AcquireGIL()
+	defer rt.ReleaseGIL()
+	core.GLOBAL_ENV.InitEnv(os.Stdin, os.Stdout, os.Stderr, nil)
+	core.ProcessCoreData()
+	core.GLOBAL_ENV.ReferCoreToUser()
+	core.GLOBAL_ENV.SetClassPath("")
+}
+
+// NewInterpreter returns an interpreter configured by opts.
+// It panics if opts.Namespace already exists.
+func NewInterpreter(opts Options) *Interpreter {
+	initOnce.Do(initCore)
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = fmt.Sprintf("user-%d", atomic.AddInt64(&interpreterCount, 1))
+	}
+	in := &Interpreter{
+		opts: opts,
+		rt:   core.NewRuntime(),
+	}
+	in.rt.AcquireGIL()
+	defer in.rt.ReleaseGIL()
+	env := core.GLOBAL_ENV
+	sym := core.MakeSymbol(opts.Namespace)
+	if ns := env.FindNamespace(sym); ns != nil {
+		if core.IsNamespaceOwned(ns) {
+			panic("joker: namespace " + opts.Namespace + " belongs to another interpreter")
+		}
+		panic("joker: namespace " + opts.Namespace + " already exists")
+	}
+	in.rt.Isolate()
+	in.ns = env.EnsureSymbolIsNamespace(sym)
+	in.rt.OwnNamespace(in.ns)
+	in.ns.ReferAll(env.CoreNamespace)
+	args := core.EmptyArrayVector()
+	for _, arg := range opts.Args {
+		args.Append(core.MakeString(arg))
+	}
+	var argsSeq Object = core.NIL
+	if args.Count() > 0 {
+		argsSeq = args.Seq()
+	}
+	bindings := core.EmptyArrayMap()
+	bindings.Add(coreVar("*ns*"), in.ns)
+	bindings.Add(coreVar("*in*"), core.MakeBufferedReader(opts.Stdin))
+	bindings.Add(coreVar("*out*"), core.MakeIOWriter(opts.Stdout))
+	bindings.Add(coreVar("*err*"), core.MakeIOWriter(opts.Stderr))
+	bindings.Add(coreVar("*command-line-args*"), argsSeq)
+	bindings.Add(coreVar("*classpath*"), classPath(opts.ClassPath))
+	in.rt.PushThreadBindings(bindings)
+	return in
+}
+
+func coreVar(name string) *core.Var {
+	return core.GLOBAL_ENV.CoreNamespace.Resolve(name)
+}
+
+func classPath(cp string) Object {
+	res := core.EmptyArrayVector()
+	for _, dir := range filepath.SplitList(cp) {
+		res.Append(core.MakeString(dir))
+	}
+	if res.Count() == 0 {
+		res.Append(core.MakeString(""))
+	}
+	return res
+}
+
+// Namespace returns the name of the namespace
+// the interpreter's code runs in.
+func (in *Interpreter) Namespace() string {
+	return in.opts.Namespace
+}
+
+// Close removes the namespaces of the interpreter, so that their vars
+// can be garbage collected and their names can be used again.
+// The interpreter can't be used after Close.
+func (in *Interpreter) Close() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.closed {
+		return
+	}
+	in.closed = true
+	in.rt.AcquireGIL()
+	in.rt.PopThreadBindings()
+	in.rt.RemoveOwnNamespaces()
+	in.rt.ReleaseGIL()
+}
+
+// run calls f holding the GIL, within the interpreter's limits.
+// Joker errors thrown by f are returned as errors.
+func (in *Interpreter) run(f func() (Object, error)) (res Object, err error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.closed {
+		return nil, errClosed
+	}
+	in.rt.AcquireGIL()
+	defer in.rt.ReleaseGIL()
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(core.Error)
+			if !ok {
+				panic(r)
+			}
+			res, err = nil, e
+		}
+	}()
+	if in.opts.Timeout > 0 || in.opts.MaxSteps > 0 || in.opts.MaxAlloc > 0 {
+		res = in.rt.WithLimits(in.opts.Timeout, in.opts.MaxSteps, in.opts.MaxAlloc, func() Object {
+			res, err = f()
+			return res
+		})
+		return res, err
+	}
+	return f()
+}
+
+// Eval evaluates the forms in code and returns
+// the value of the last one (nil if there are none).
+func (in *Interpreter) Eval(code string) (Object, error) {
+	return in.run(func() (Object, error) {
+		return evalReader(core.NewReader(strings.NewReader(code), "<eval>"))
+	})
+}
+
+// LoadFile evaluates the forms in the file and returns
+// the value of the last one, like load-file.
+func (in *Interpreter) LoadFile(filename string) (Object, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return in.run(func() (Object, error) {
+		bindings := core.EmptyArrayMap()
+		bindings.Add(coreVar("*file*"), core.MakeString(path))
+		in.rt.PushThreadBindings(bindings)
+		defer in.rt.PopThreadBindings()
+		return evalReader(core.NewReader(bufio.NewReader(f), filename))
+	})
+}
+
+func evalReader(reader *core.Reader) (Object, error) {
+	ctx := &core.ParseContext{GlobalEnv: core.GLOBAL_ENV}
+	var res Object = core.NIL
+	for {
+		obj, err := core.TryRead(reader)
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		expr, err := core.TryParse(obj, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if res, err = core.TryEval(expr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Call calls fn (e.g. a function returned by Eval) with args,
+// converted with ToJoker.
+func (in *Interpreter) Call(fn Object, args ...interface{}) (Object, error) {
+	callable, ok := fn.(core.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", fn.ToString(false))
+	}
+	objs := make([]Object, len(args))
+	for i, arg := range args {
+		obj, err := ToJoker(arg)
+		if err != nil {
+			return nil, err
+		}
+		objs[i] = obj
+	}
+	return in.run(func() (Object, error) {
+		return callable.Call(objs), nil
+	})
+}
+
+// ToGo converts obj to a Go value, like ToGo,
+// realizing lazy seqs in the interpreter.
+func (in *Interpreter) ToGo(obj Object) (interface{}, error) {
+	var res interface{}
+	_, err := in.run(func() (Object, error) {
+		var err error
+		res, err = ToGo(obj)
+		return core.NIL, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetVar defines a var with the given name in the interpreter's
+// namespace, with value converted with ToJoker. Go funcs are
+// converted with Func, named after the var.
+func (in *Interpreter) SetVar(name string, value interface{}) error {
+	obj, err := toJokerNamed(in.opts.Namespace+"/"+name, value)
+	if err != nil {
+		return err
+	}
+	sym := core.MakeSymbol(name)
+	if sym.Namespace() != "" {
+		return fmt.Errorf("var name must not be namespace-qualified: %s", name)
+	}
+	_, err = in.run(func() (Object, error) {
+		in.ns.Intern(sym).Value = obj
+		return core.NIL, nil
+	})
+	return err
+}
+
+// GetVar returns the value of the var the symbol name resolves to
+// in the interpreter's namespace.
+func (in *Interpreter) GetVar(name string) (Object, bool) {
+	var found bool
+	res, err := in.run(func() (Object, error) {
+		vr, ok := core.GLOBAL_ENV.ResolveIn(in.ns, core.MakeSymbol(name))
+		if !ok || vr.Value == nil {
+			return core.NIL, nil
+		}
+		found = true
+		return vr.Value, nil
+	})
+	return res, found && err == nil
+}
@@ -0,0 +1,388 @@
+package joker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eval evaluates code, which must succeed, and returns its value
+// as printed by pr-str.
+func eval(t *testing.T, in *Interpreter, code string) string {
+	t.Helper()
+	res, err := in.Eval(code)
+	if err != nil {
+		t.Fatalf("%s: %s", code, err)
+	}
+	return res.ToString(true)
+}
+
+func TestEval(t *testing.T) {
+	in := NewInterpreter(Options{})
+	tests := []struct {
+		code, expected string
+	}{
+		{"(+ 1 2)", "3"},
+		{"(def x 10) (inc x)", "11"},
+		{"x", "10"},
+		{"", "nil"},
+		{`(str "a" "b")`, `"ab"`},
+		{"(filter odd? (range 5))", "(1 3)"},
+		{"(joker.string/upper-case \"ok\")", `"OK"`},
+	}
+	for _, test := range tests {
+		if res := eval(t, in, test.code); res != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.code, test.expected, res)
+		}
+	}
+	errors := []struct {
+		code, expected string
+	}{
+		{"(+ 1", "Unexpected end of file"},
+		{"(undefined-fn 1)", "Unable to resolve symbol: undefined-fn"},
+		{`(throw (ex-info "boom" {}))`, "boom"},
+		{"(/ 1 0)", "Division by zero"},
+	}
+	for _, test := range errors {
+		if msg := evalError(t, in, test.code); !strings.Contains(msg, test.expected) {
+			t.Errorf("%s: expected error containing %q, got %q", test.code, test.expected, msg)
+		}
+	}
+	// Forms before the failing one are evaluated.
+	evalError(t, in, "(def y 1) (/ 1 0)")
+	if res := eval(t, in, "y"); res != "1" {
+		t.Errorf("expected y to be defined, got %s", res)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "rules.joke")
+	code := "(defn allowed? [n] (< n limit))\n(allowed? 5)\n"
+	if err := os.WriteFile(filename, []byte(code), 0666); err != nil {
+		t.Fatal(err)
+	}
+	in := NewInterpreter(Options{})
+	if err := in.SetVar("limit", 10); err != nil {
+		t.Fatal(err)
+	}
+	res, err := in.LoadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToString(true) != "true" {
+		t.Errorf("expected true, got %s", res.ToString(true))
+	}
+	fn, ok := in.GetVar("allowed?")
+	if !ok {
+		t.Fatal("allowed? isn't defined")
+	}
+	if res, err := in.Call(fn, 20); err != nil || res.ToString(true) != "false" {
+		t.Errorf("expected false, got %v, %v", res, err)
+	}
+	if _, err := in.LoadFile(filepath.Join(dir, "missing.joke")); err == nil {
+		t.Error("expected an error loading a missing file")
+	}
+	bad := filepath.Join(dir, "bad.joke")
+	if err := os.WriteFile(bad, []byte("(inc :a)"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.LoadFile(bad); err == nil || !strings.Contains(err.Error(), "bad.joke") {
+		t.Errorf("expected an error pointing to bad.joke, got %v", err)
+	}
+}
+
+func TestSetVarGetVar(t *testing.T) {
+	in := NewInterpreter(Options{})
+	vars := map[string]interface{}{
+		"n":    42,
+		"s":    "str",
+		"v":    []int{1, 2},
+		"m":    map[string]int{"a": 1},
+		"none": nil,
+	}
+	for name, value := range vars {
+		if err := in.SetVar(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := eval(t, in, "[n s v m none]"); res != `[42 "str" [1 2] {"a" 1} nil]` {
+		t.Errorf("unexpected values: %s", res)
+	}
+	if err := in.SetVar("n", 43); err != nil {
+		t.Fatal(err)
+	}
+	if res, ok := in.GetVar("n"); !ok || res.ToString(true) != "43" {
+		t.Errorf("expected 43, got %v", res)
+	}
+	eval(t, in, "(def from-joker {:a [1 2]})")
+	res, ok := in.GetVar("from-joker")
+	if !ok {
+		t.Fatal("from-joker isn't defined")
+	}
+	v, err := in.ToGo(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]interface{}{"a": []interface{}{1, 2}}; !reflect.DeepEqual(v, expected) {
+		t.Errorf("expected %v, got %v", expected, v)
+	}
+	if _, ok := in.GetVar("undefined"); ok {
+		t.Error("undefined var found")
+	}
+	if res, ok := in.GetVar("joker.core/inc"); !ok || res == nil {
+		t.Error("joker.core/inc not found")
+	}
+	if err := in.SetVar("other.ns/x", 1); err == nil {
+		t.Error("expected an error setting a namespace-qualified var")
+	}
+	if err := in.SetVar("bad", struct{}{}); err == nil {
+		t.Error("expected an error setting a var to a struct")
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	in1 := NewInterpreter(Options{})
+	in2 := NewInterpreter(Options{})
+	if in1.Namespace() == in2.Namespace() {
+		t.Fatalf("interpreters share namespace %s", in1.Namespace())
+	}
+	eval(t, in1, "(def x 1)")
+	eval(t, in2, "(def x 2)")
+	if res := eval(t, in1, "x"); res != "1" {
+		t.Errorf("expected 1, got %s", res)
+	}
+	if res := eval(t, in2, "x"); res != "2" {
+		t.Errorf("expected 2, got %s", res)
+	}
+	if err := in1.SetVar("only-in-1", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := in2.GetVar("only-in-1"); ok {
+		t.Error("var of one interpreter found in the other")
+	}
+	evalError(t, in2, "only-in-1")
+	if res := eval(t, in2, "(str *ns*)"); res != `"`+in2.Namespace()+`"` {
+		t.Errorf("expected *ns* to be %s, got %s", in2.Namespace(), res)
+	}
+	named := NewInterpreter(Options{Namespace: "test-named"})
+	defer named.Close()
+	if res := eval(t, named, "(str *ns*)"); res != `"test-named"` {
+		t.Errorf("expected *ns* to be test-named, got %s", res)
+	}
+}
+
+func TestNamespaceOwnership(t *testing.T) {
+	in1 := NewInterpreter(Options{})
+	defer in1.Close()
+	in2 := NewInterpreter(Options{})
+	defer in2.Close()
+	if err := in2.SetVar("x", 2); err != nil {
+		t.Fatal(err)
+	}
+	ns2 := in2.Namespace()
+	errors := []string{
+		"(in-ns '" + ns2 + ") (def x 1)",
+		"(binding [*ns* (the-ns '" + ns2 + ")] (eval '(def x 1)))",
+		"(intern '" + ns2 + " 'x 1)",
+		"(var-set #'" + ns2 + "/x 1)",
+		"(ns-unmap '" + ns2 + " 'x)",
+		"(remove-ns '" + ns2 + ")",
+		"@(future (intern '" + ns2 + " 'x 1))",
+	}
+	for _, code := range errors {
+		if msg := evalError(t, in1, code); !strings.Contains(msg, "belongs to another interpreter") {
+			t.Errorf("%s: unexpected error %s", code, msg)
+		}
+	}
+	if res := eval(t, in1, ns2+"/x"); res != "2" {
+		t.Errorf("expected other interpreter's var to be readable, got %s", res)
+	}
+	if res := eval(t, in2, "x"); res != "2" {
+		t.Errorf("expected 2, got %s", res)
+	}
+	if res := eval(t, in2, "@(future (def y 3)) y"); res != "3" {
+		t.Errorf("expected 3, got %s", res)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic creating an interpreter with the namespace of another one")
+		}
+	}()
+	NewInterpreter(Options{Namespace: ns2})
+}
+
+func TestSharedNamespaces(t *testing.T) {
+	in := NewInterpreter(Options{})
+	defer in.Close()
+	errors := []string{
+		"(intern 'joker.core 'inc dec)",
+		"(var-set #'joker.core/inc dec)",
+		"(alter-meta! #'joker.core/inc assoc :private true)",
+		"(alter-meta! (the-ns 'joker.core) assoc :doc \"changed\")",
+		"(ns-unmap 'joker.core 'inc)",
+		"(in-ns 'joker.core)",
+		"(binding [*ns* (the-ns 'joker.core)] (eval '(def inc dec)))",
+		"(require 'joker.string) (intern 'joker.string 'join 1)",
+		"@(future (intern 'joker.core 'inc dec))",
+	}
+	for _, code := range errors {
+		if msg := evalError(t, in, code); !strings.Contains(msg, "is shared and can't be changed by an interpreter") {
+			t.Errorf("%s: unexpected error %s", code, msg)
+		}
+	}
+	other := NewInterpreter(Options{})
+	defer other.Close()
+	if res := eval(t, other, "[(inc 1) (:private (meta #'inc)) (joker.string/join [1 2])]"); res != `[2 nil "12"]` {
+		t.Errorf("shared namespaces were changed: %s", res)
+	}
+}
+
+func TestCreatedNamespaces(t *testing.T) {
+	in1 := NewInterpreter(Options{})
+	in2 := NewInterpreter(Options{})
+	defer in2.Close()
+	eval(t, in1, "(ns test-created.a) (def x 1) (create-ns 'test-created.b)")
+	for _, code := range []string{"(intern 'test-created.a 'x 2)", "(in-ns 'test-created.b)"} {
+		if msg := evalError(t, in2, code); !strings.Contains(msg, "belongs to another interpreter") {
+			t.Errorf("%s: unexpected error %s", code, msg)
+		}
+	}
+	if res := eval(t, in2, "test-created.a/x"); res != "1" {
+		t.Errorf("expected 1, got %s", res)
+	}
+	in1.Close()
+	if res := eval(t, in2, "[(find-ns 'test-created.a) (find-ns 'test-created.b) (contains? (loaded-libs) 'test-created.a)]"); res != "[nil nil false]" {
+		t.Errorf("expected the namespaces to be removed, got %s", res)
+	}
+	eval(t, in2, "(ns test-created.a) (def x 2)")
+}
+
+func TestLibNamespaces(t *testing.T) {
+	// Keep the lib cache out of the home directory.
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "test-lib"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	code := "(ns test-lib.core)\n(defn f [x] (* 2 x))\n"
+	if err := os.WriteFile(filepath.Join(dir, "test-lib", "core.joke"), []byte(code), 0666); err != nil {
+		t.Fatal(err)
+	}
+	in1 := NewInterpreter(Options{ClassPath: dir})
+	in2 := NewInterpreter(Options{ClassPath: dir})
+	defer in2.Close()
+	if res := eval(t, in1, "(require '[test-lib.core :as lib]) (lib/f 1)"); res != "2" {
+		t.Errorf("expected 2, got %s", res)
+	}
+	in1.Close()
+	if res := eval(t, in2, "(require '[test-lib.core :as lib]) (lib/f 2)"); res != "4" {
+		t.Errorf("expected 4, got %s", res)
+	}
+	for _, code := range []string{"(intern 'test-lib.core 'f inc)", "(require 'test-lib.core :reload)"} {
+		if msg := evalError(t, in2, code); !strings.Contains(msg, "is shared") {
+			t.Errorf("%s: unexpected error %s", code, msg)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	in := NewInterpreter(Options{Namespace: "test-close"})
+	eval(t, in, "(def x 1)")
+	in.Close()
+	in.Close()
+	if _, err := in.Eval("x"); err == nil {
+		t.Error("expected an error evaluating code in a closed interpreter")
+	}
+	if err := in.SetVar("y", 1); err == nil {
+		t.Error("expected an error setting a var in a closed interpreter")
+	}
+	other := NewInterpreter(Options{})
+	defer other.Close()
+	if res := eval(t, other, "(find-ns 'test-close)"); res != "nil" {
+		t.Errorf("expected the namespace to be removed, got %s", res)
+	}
+	reused := NewInterpreter(Options{Namespace: "test-close"})
+	defer reused.Close()
+	evalError(t, reused, "x")
+}
+
+func TestOptions(t *testing.T) {
+	var out, errOut bytes.Buffer
+	in := NewInterpreter(Options{
+		Stdin:  strings.NewReader("input line\n"),
+		Stdout: &out,
+		Stderr: &errOut,
+		Args:   []string{"a", "b"},
+	})
+	eval(t, in, `(println (read-line)) (binding [*out* *err*] (print "to err")) (prn *command-line-args*)`)
+	if out.String() != "input line\n(\"a\" \"b\")\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+	if errOut.String() != "to err" {
+		t.Errorf("unexpected error output: %q", errOut.String())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	in := NewInterpreter(Options{Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	msg := evalError(t, in, "(loop [] (recur))")
+	if !strings.Contains(msg, "Timeout") {
+		t.Errorf("expected a timeout, got %s", msg)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("timeout took %s", elapsed)
+	}
+	// Each call gets the full time again.
+	if res := eval(t, in, "(+ 1 2)"); res != "3" {
+		t.Errorf("expected 3, got %s", res)
+	}
+	fn, err := in.Eval("(fn [] (loop [] (recur)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Call(fn); err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("expected Call to time out, got %v", err)
+	}
+	steps := NewInterpreter(Options{MaxSteps: 100})
+	if msg := evalError(t, steps, "(dotimes [i 1000] i)"); !strings.Contains(msg, "Step limit exceeded") {
+		t.Errorf("expected the step limit to be exceeded, got %s", msg)
+	}
+	alloc := NewInterpreter(Options{MaxAlloc: 1 << 20})
+	if msg := evalError(t, alloc, "(vec (range 1e9))"); !strings.Contains(msg, "Allocation limit exceeded") {
+		t.Errorf("expected the allocation limit to be exceeded, got %s", msg)
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := NewInterpreter(Options{})
+	fn, err := in.Eval("(fn [m k] (get m k))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := in.Call(fn, map[string]int{"a": 1}, "a")
+	if err != nil || res.ToString(true) != "1" {
+		t.Errorf("expected 1, got %v, %v", res, err)
+	}
+	if _, err := in.Call(fn, 1); err == nil || !strings.Contains(err.Error(), "Wrong number of args") {
+		t.Errorf("expected an arity error, got %v", err)
+	}
+	if _, err := in.Call(mustToJoker(t, 1)); err == nil || !strings.Contains(err.Error(), "is not a function") {
+		t.Errorf("expected an error calling a number, got %v", err)
+	}
+}
+
+// mustToJoker converts v with ToJoker, which must succeed.
+func mustToJoker(t *testing.T, v interface{}) Object {
+	t.Helper()
+	res, err := ToJoker(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -0,0 +1,314 @@
+package joker
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/candid82/joker/core"
+)
+
+type (
+	// argConverter converts a Joker argument to a Go parameter value.
+	// ok is false if obj doesn't have the expected type.
+	argConverter func(obj Object) (v reflect.Value, ok bool)
+
+	param struct {
+		convert  argConverter
+		expected string // Joker type expected, for error messages
+	}
+)
+
+var (
+	objectType  = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+	bigIntType  = reflect.TypeOf((*big.Int)(nil))
+	emptyIfType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// Func returns a Joker function (a Proc) named name that calls the Go
+// function f. Arguments are checked against and converted to the types
+// of f's parameters, which can be:
+//
+//	bool                               Boolean
+//	signed and unsigned integers       Int (in range of the type)
+//	float32, float64                   any number
+//	string                             String
+//	*big.Int                           Int or BigInt
+//	time.Time                          Time
+//	slices                             vector, list, seq or set of elements
+//	                                   of the element type
+//	maps                               map of the key and value types
+//	interface{}                        anything, converted with ToGo
+//	Object or types implementing it    Joker values of that type, as is
+//
+// f may be variadic. It may return nothing, a value, an error,
+// or a value and an error. Values are converted with ToJoker, and
+// errors are thrown as Joker errors.
+func Func(name string, f interface{}) (Object, error) {
+	v := reflect.ValueOf(f)
+	t := v.Type()
+	if t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s: expected a func, got %s", name, t)
+	}
+	params := make([]param, t.NumIn())
+	for i := range params {
+		pt := t.In(i)
+		if t.IsVariadic() && i == len(params)-1 {
+			pt = pt.Elem()
+		}
+		convert, expected := converterFor(pt)
+		if convert == nil {
+			return nil, fmt.Errorf("%s: unsupported parameter type %s", name, pt)
+		}
+		params[i] = param{convert: convert, expected: expected}
+	}
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	switch {
+	case t.NumOut() > 2, t.NumOut() == 2 && !returnsError:
+		return nil, fmt.Errorf("%s: func must return at most a value and an error", name)
+	}
+	min, max := len(params), len(params)
+	if t.IsVariadic() {
+		min, max = len(params)-1, math.MaxInt32
+	}
+	return core.Proc{
+		Fn: func(args []Object) Object {
+			if len(args) < min || len(args) > max {
+				panic(core.RT.NewError(fmt.Sprintf("Wrong number of args (%d) passed to %s", len(args), name)))
+			}
+			in := make([]reflect.Value, len(args))
+			for i, arg := range args {
+				p := params[len(params)-1]
+				if i < len(params) {
+					p = params[i]
+				}
+				v, ok := p.convert(arg)
+				if !ok {
+					panic(core.RT.NewError(fmt.Sprintf("Arg[%d] of %s must have type %s, got %s", i, name, p.expected, arg.GetType().ToString(false))))
+				}
+				in[i] = v
+			}
+			out := call(name, v, in)
+			if returnsError {
+				if err := out[len(out)-1]; !err.IsNil() {
+					panic(core.RT.NewError(err.Interface().(error).Error()))
+				}
+				out = out[:len(out)-1]
+			}
+			if len(out) == 0 {
+				return core.NIL
+			}
+			res, err := ToJoker(out[0].Interface())
+			if err != nil {
+				panic(core.RT.NewError(fmt.Sprintf("%s: %s", name, err)))
+			}
+			return res
+		},
+		Name: name,
+	}, nil
+}
+
+// call calls f with in. Go panics (e.g. integer division by zero)
+// are thrown as Joker errors, while Joker errors thrown by code f calls
+// back into pass through as is.
+func call(name string, f reflect.Value, in []reflect.Value) []reflect.Value {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(core.Error); ok {
+				panic(r)
+			}
+			panic(core.RT.NewError(fmt.Sprintf("%s: %v", name, r)))
+		}
+	}()
+	return f.Call(in)
+}
+
+// converterFor returns the converter of Joker values to values of type t,
+// or nil if t isn't supported.
+func converterFor(t reflect.Type) (argConverter, string) {
+	switch {
+	case t == objectType:
+		return func(obj Object) (reflect.Value, bool) {
+			return reflect.ValueOf(&obj).Elem(), true
+		}, "Object"
+	case t == emptyIfType:
+		return func(obj Object) (reflect.Value, bool) {
+			v, err := ToGo(obj)
+			if err != nil {
+				return reflect.Value{}, false
+			}
+			res := reflect.New(t).Elem()
+			if v != nil {
+				res.Set(reflect.ValueOf(v))
+			}
+			return res, true
+		}, "Object"
+	case t.Implements(objectType):
+		return func(obj Object) (reflect.Value, bool) {
+			v := reflect.ValueOf(obj)
+			if !v.Type().AssignableTo(t) {
+				return reflect.Value{}, false
+			}
+			res := reflect.New(t).Elem()
+			res.Set(v)
+			return res, true
+		}, t.Name()
+	case t == timeType:
+		return func(obj Object) (reflect.Value, bool) {
+			tm, ok := obj.(core.Time)
+			return reflect.ValueOf(tm.T), ok
+		}, "Time"
+	case t == bigIntType:
+		return func(obj Object) (reflect.Value, bool) {
+			switch n := obj.(type) {
+			case core.Int, *core.BigInt:
+				return reflect.ValueOf(n.(core.Number).BigInt()), true
+			}
+			return reflect.Value{}, false
+		}, "Int or BigInt"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(obj Object) (reflect.Value, bool) {
+			b, ok := obj.(core.Boolean)
+			return reflect.ValueOf(b.B).Convert(t), ok
+		}, "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(obj Object) (reflect.Value, bool) {
+			i, ok := obj.(core.Int)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			res := reflect.New(t).Elem()
+			if res.OverflowInt(int64(i.I)) {
+				return reflect.Value{}, false
+			}
+			res.SetInt(int64(i.I))
+			return res, true
+		}, fmt.Sprintf("Int (%s)", t.Kind())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(obj Object) (reflect.Value, bool) {
+			i, ok := obj.(core.Int)
+			if !ok || i.I < 0 {
+				return reflect.Value{}, false
+			}
+			res := reflect.New(t).Elem()
+			if res.OverflowUint(uint64(i.I)) {
+				return reflect.Value{}, false
+			}
+			res.SetUint(uint64(i.I))
+			return res, true
+		}, fmt.Sprintf("Int (%s)", t.Kind())
+	case reflect.Float32, reflect.Float64:
+		return func(obj Object) (reflect.Value, bool) {
+			n, ok := obj.(core.Number)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			res := reflect.New(t).Elem()
+			res.SetFloat(n.Double().D)
+			return res, true
+		}, "Number"
+	case reflect.String:
+		return func(obj Object) (reflect.Value, bool) {
+			s, ok := obj.(core.String)
+			return reflect.ValueOf(s.S).Convert(t), ok
+		}, "String"
+	case reflect.Slice:
+		elem, expected := converterFor(t.Elem())
+		if elem == nil {
+			return nil, ""
+		}
+		return func(obj Object) (reflect.Value, bool) {
+			switch obj.(type) {
+			case core.Vec, core.Seq, core.Set:
+			default:
+				return reflect.Value{}, false
+			}
+			res := reflect.MakeSlice(t, 0, 0)
+			for s := obj.(core.Seqable).Seq(); !s.IsEmpty(); s = s.Rest() {
+				v, ok := elem(s.First())
+				if !ok {
+					return reflect.Value{}, false
+				}
+				res = reflect.Append(res, v)
+			}
+			return res, true
+		}, "Seqable of " + expected
+	case reflect.Map:
+		key, expectedKey := converterFor(t.Key())
+		value, expectedValue := converterFor(t.Elem())
+		if key == nil || value == nil {
+			return nil, ""
+		}
+		return func(obj Object) (reflect.Value, bool) {
+			m, ok := obj.(core.Map)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			res := reflect.MakeMapWithSize(t, m.Count())
+			for iter := m.Iter(); iter.HasNext(); {
+				p := iter.Next()
+				k, ok := key(p.Key)
+				if !ok || k.Kind() == reflect.Interface && !k.IsNil() && !k.Elem().Type().Comparable() {
+					return reflect.Value{}, false
+				}
+				v, ok := value(p.Value)
+				if !ok {
+					return reflect.Value{}, false
+				}
+				res.SetMapIndex(k, v)
+			}
+			return res, true
+		}, "Map of " + expectedKey + " to " + expectedValue
+	}
+	return nil, ""
+}
+
+// DefineNamespace creates the namespace name (or adds to it if it exists)
+// with the given docstring and vars. Values of vars are converted with
+// Func if they are Go funcs and with ToJoker otherwise. The namespace
+// can then be required by Joker code like the standard library ones,
+// without generating code for it.
+//
+// DefineNamespace must not be called by Go functions called
+// from Joker code, which already hold the GIL.
+func DefineNamespace(name, doc string, vars map[string]interface{}) error {
+	initOnce.Do(initCore)
+	values := make(map[string]Object, len(vars))
+	for varName, v := range vars {
+		obj, err := toJokerNamed(name+"/"+varName, v)
+		if err != nil {
+			return err
+		}
+		values[varName] = obj
+	}
+	rt := core.NewRuntime()
+	rt.AcquireGIL()
+	defer rt.ReleaseGIL()
+	ns := core.GLOBAL_ENV.EnsureSymbolIsLib(core.MakeSymbol(name))
+	if doc != "" {
+		ns.ResetMeta(core.MakeMeta(nil, doc, ""))
+	}
+	for varName, obj := range values {
+		ns.InternVar(varName, obj, core.MakeMeta(nil, "", ""))
+	}
+	return nil
+}
+
+// toJokerNamed is like ToJoker, but names the function
+// returned for a Go func name.
+func toJokerNamed(name string, v interface{}) (Object, error) {
+	switch v.(type) {
+	case func([]Object) Object, core.ProcFn:
+	default:
+		if v != nil && reflect.TypeOf(v).Kind() == reflect.Func {
+			return Func(name, v)
+		}
+	}
+	return ToJoker(v)
+}
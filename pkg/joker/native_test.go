@@ -0,0 +1,217 @@
+package joker
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/candid82/joker/core"
+)
+
+// evalError evaluates code, which must fail, and returns the error message.
+func evalError(t *testing.T, in *Interpreter, code string) string {
+	t.Helper()
+	res, err := in.Eval(code)
+	if err == nil {
+		t.Fatalf("%s: expected an error, got %s", code, res.ToString(true))
+	}
+	return err.Error()
+}
+
+func TestFuncPanics(t *testing.T) {
+	in := NewInterpreter(Options{})
+	if err := in.SetVar("div", func(a, b int) int { return a / b }); err != nil {
+		t.Fatal(err)
+	}
+	msg := evalError(t, in, "(div 1 0)")
+	if !strings.Contains(msg, "integer divide by zero") {
+		t.Errorf("unexpected error: %s", msg)
+	}
+	res, err := in.Eval(`(try (div 1 0) (catch Error e :caught))`)
+	if err != nil || res.ToString(false) != ":caught" {
+		t.Errorf("expected :caught, got %v, %v", res, err)
+	}
+	// The interpreter is still usable.
+	if res, err := in.Eval("(div 6 3)"); err != nil || res.ToString(false) != "2" {
+		t.Errorf("expected 2, got %v, %v", res, err)
+	}
+}
+
+func newNativeInterpreter(t *testing.T, fns map[string]interface{}) *Interpreter {
+	t.Helper()
+	in := NewInterpreter(Options{Namespace: "native-test"})
+	t.Cleanup(in.Close)
+	for name, f := range fns {
+		if err := in.SetVar(name, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return in
+}
+
+func TestFuncParams(t *testing.T) {
+	in := newNativeInterpreter(t, map[string]interface{}{
+		"negate":   func(b bool) bool { return !b },
+		"add8":     func(a, b int8) int8 { return a + b },
+		"add64":    func(a, b int64) int64 { return a + b },
+		"uint":     func(u uint32) uint32 { return u },
+		"half":     func(f float64) float64 { return f / 2 },
+		"half32":   func(f float32) float32 { return f / 2 },
+		"upper":    strings.ToUpper,
+		"bigsq":    func(n *big.Int) *big.Int { return new(big.Int).Mul(n, n) },
+		"year":     func(tm time.Time) int { return tm.Year() },
+		"sum":      func(xs []int) int { return sumInts(xs...) },
+		"nested":   func(xss [][]string) int { return len(xss) },
+		"map-keys": func(m map[string]int) []string { return sortedMapKeys(m) },
+		"any":      func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"obj":      func(obj Object) string { return obj.GetType().ToString(false) },
+		"kw":       func(k core.Keyword) string { return k.Name() },
+		"varsum":   func(base int, xs ...int) int { return base + sumInts(xs...) },
+		"nothing":  func() {},
+		"fails":    func(fail bool) (string, error) { return "ok", errorIf(fail) },
+		"onlyerr":  func(fail bool) error { return errorIf(fail) },
+	})
+	tests := []struct {
+		code, expected string
+	}{
+		{"(negate true)", "false"},
+		{"(add8 100 27)", "127"},
+		{"(add64 1 2)", "3"},
+		{"(uint 4294967295)", "4294967295"},
+		{"(half 3)", "1.5"},
+		{"(half 1/2)", "0.25"},
+		{"(half32 1.0)", "0.5"},
+		{`(upper "abc")`, `"ABC"`},
+		{"(bigsq 10000000000)", "100000000000000000000N"},
+		{"(bigsq 3N)", "9N"},
+		{`(year (joker.time/parse "2006-01-02" "2021-05-06"))`, "2021"},
+		{"(sum [1 2 3])", "6"},
+		{"(sum '(1 2))", "3"},
+		{"(sum (range 4))", "6"},
+		{"(sum #{5})", "5"},
+		{"(sum [])", "0"},
+		{`(nested [["a"] []])`, "2"},
+		{`(map-keys {"b" 2 "a" 1})`, `["a" "b"]`},
+		{"(any 1)", `"int"`},
+		{"(any nil)", `"<nil>"`},
+		{"(any {:a [1]})", `"map[string]interface {}"`},
+		{"(obj :a)", `"Keyword"`},
+		{"(kw :abc)", `"abc"`},
+		{"(varsum 1)", "1"},
+		{"(varsum 1 2 3)", "6"},
+		{"(nothing)", "nil"},
+		{"(fails false)", `"ok"`},
+		{"(onlyerr false)", "nil"},
+	}
+	for _, test := range tests {
+		if res := eval(t, in, test.code); res != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.code, test.expected, res)
+		}
+	}
+}
+
+func TestFuncErrors(t *testing.T) {
+	in := newNativeInterpreter(t, map[string]interface{}{
+		"add8":     func(a, b int8) int8 { return a + b },
+		"uint":     func(u uint32) uint32 { return u },
+		"upper":    strings.ToUpper,
+		"sum":      func(xs []int) int { return sumInts(xs...) },
+		"map-keys": func(m map[string]int) []string { return sortedMapKeys(m) },
+		"kw":       func(k core.Keyword) string { return k.Name() },
+		"varsum":   func(base int, xs ...int) int { return base + sumInts(xs...) },
+		"fails":    func(fail bool) (string, error) { return "ok", errorIf(fail) },
+		"onlyerr":  func(fail bool) error { return errorIf(fail) },
+		"bad":      func() chan int { return make(chan int) },
+	})
+	tests := []struct {
+		code, expected string
+	}{
+		{"(add8 1)", "Wrong number of args (1) passed to native-test/add8"},
+		{"(add8 1 2 3)", "Wrong number of args (3) passed to native-test/add8"},
+		{"(varsum)", "Wrong number of args (0) passed to native-test/varsum"},
+		{`(add8 1 "2")`, "Arg[1] of native-test/add8 must have type Int (int8), got String"},
+		{"(add8 1 128)", "Arg[1] of native-test/add8 must have type Int (int8), got Int"},
+		{"(uint -1)", "Arg[0] of native-test/uint must have type Int (uint32), got Int"},
+		{"(upper :a)", "Arg[0] of native-test/upper must have type String, got Keyword"},
+		{`(sum [1 "a"])`, "Arg[0] of native-test/sum must have type Seqable of Int (int), got ArrayVector"},
+		{"(sum 1)", "Arg[0] of native-test/sum must have type Seqable of Int (int), got Int"},
+		{`(map-keys {:a 1})`, "Arg[0] of native-test/map-keys must have type Map of String to Int (int), got ArrayMap"},
+		{`(kw "a")`, "Arg[0] of native-test/kw must have type Keyword, got String"},
+		{`(varsum 1 2 "3")`, "Arg[2] of native-test/varsum must have type Int (int), got String"},
+		{"(fails true)", "failed"},
+		{"(onlyerr true)", "failed"},
+		{"(bad)", "native-test/bad: can't convert chan int to a Joker value"},
+	}
+	for _, test := range tests {
+		if msg := evalError(t, in, test.code); !strings.Contains(msg, test.expected) {
+			t.Errorf("%s: expected error containing %q, got %q", test.code, test.expected, msg)
+		}
+	}
+}
+
+func TestFuncDefinitionErrors(t *testing.T) {
+	tests := []struct {
+		f        interface{}
+		expected string
+	}{
+		{42, "f: expected a func, got int"},
+		{func(struct{}) {}, "f: unsupported parameter type struct {}"},
+		{func([]chan int) {}, "f: unsupported parameter type []chan int"},
+		{func() (int, int) { return 0, 0 }, "f: func must return at most a value and an error"},
+		{func() (int, string, error) { return 0, "", nil }, "f: func must return at most a value and an error"},
+	}
+	for _, test := range tests {
+		if _, err := Func("f", test.f); err == nil || err.Error() != test.expected {
+			t.Errorf("%T: expected error %q, got %v", test.f, test.expected, err)
+		}
+	}
+}
+
+func TestDefineNamespace(t *testing.T) {
+	err := DefineNamespace("native-test.lib", "Functions for tests.", map[string]interface{}{
+		"double": func(n int) int { return 2 * n },
+		"pi":     3.14,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := NewInterpreter(Options{})
+	code := `(require '[native-test.lib :as lib]) [(lib/double 21) lib/pi (:doc (meta (find-ns 'native-test.lib)))]`
+	if res := eval(t, in, code); res != `[42 3.14 "Functions for tests."]` {
+		t.Errorf("unexpected result: %s", res)
+	}
+	if msg := evalError(t, in, "(lib/double 1 2)"); !strings.Contains(msg, "Wrong number of args (2) passed to native-test.lib/double") {
+		t.Errorf("unexpected error: %s", msg)
+	}
+	if err := DefineNamespace("native-test.bad", "", map[string]interface{}{"x": struct{}{}}); err == nil {
+		t.Error("expected an error defining a var with a struct value")
+	}
+}
+
+func sumInts(xs ...int) int {
+	res := 0
+	for _, x := range xs {
+		res += x
+	}
+	return res
+}
+
+func sortedMapKeys(m map[string]int) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func errorIf(fail bool) error {
+	if fail {
+		return errors.New("failed")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// This file is generated by generate-std.joke script. Do not edit manually!
+
+package edn
+
+import (
+	. "github.com/candid82/joker/core"
+)
+
+var __read__P ProcFn = __read_
+var read_ Proc = Proc{Fn: __read__P, Name: "read_", Package: "std/edn"}
+
+func __read_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 0:
+		_res := read(nil, nil)
+		return _res
+
+	case _c == 1:
+		rdr := ExtractObject(_args, 0)
+		_res := read(rdr, nil)
+		return _res
+
+	case _c == 2:
+		opts := ExtractMap(_args, 0)
+		rdr := ExtractObject(_args, 1)
+		_res := read(rdr, opts)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __read_string__P ProcFn = __read_string_
+var read_string_ Proc = Proc{Fn: __read_string__P, Name: "read_string_", Package: "std/edn"}
+
+func __read_string_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		s := ExtractString(_args, 0)
+		_res := readString(s, nil)
+		return _res
+
+	case _c == 2:
+		opts := ExtractMap(_args, 0)
+		s := ExtractString(_args, 1)
+		_res := readString(s, opts)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __write__P ProcFn = __write_
+var write_ Proc = Proc{Fn: __write__P, Name: "write_", Package: "std/edn"}
+
+func __write_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 2:
+		wr := ExtractIOWriter(_args, 0)
+		v := ExtractObject(_args, 1)
+		_res := write(wr, v)
+		return _res
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+var __write_string__P ProcFn = __write_string_
+var write_string_ Proc = Proc{Fn: __write_string__P, Name: "write_string_", Package: "std/edn"}
+
+func __write_string_(_args []Object) Object {
+	_c := len(_args)
+	switch {
+	case _c == 1:
+		v := ExtractObject(_args, 0)
+		_res := EDNString(v)
+		return MakeString(_res)
+
+	default:
+		PanicArity(_c)
+	}
+	return NIL
+}
+
+func Init() {
+
+	InternsOrThunks()
+}
+
+var ednNamespace = GLOBAL_ENV.EnsureSymbolIsLib(MakeSymbol("joker.edn"))
+
+func init() {
+	ednNamespace.Lazy = Init
+}
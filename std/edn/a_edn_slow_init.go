@@ -0,0 +1,60 @@
+// This file is generated by generate-std.joke script. Do not edit manually!
+
+package edn
+
+import (
+	"fmt"
+	. "github.com/candid82/joker/core"
+	"os"
+)
+
+func InternsOrThunks() {
+	if VerbosityLevel > 0 {
+		fmt.Fprintln(os.Stderr, "Lazily running slow version of edn.InternsOrThunks().")
+	}
+	ednNamespace.ResetMeta(MakeMeta(nil, `Reads and writes data in edn format (https://github.com/edn-format/edn).
+  Unlike joker.core/read-string, the reader is strictly limited to edn syntax
+  and never evaluates code or resolves namespaces, which makes it safe to use
+  on untrusted input.`, "1.0"))
+
+	ednNamespace.InternVar("read", read_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(), NewVectorFrom(MakeSymbol("rdr")), NewVectorFrom(MakeSymbol("opts"), MakeSymbol("rdr"))),
+			`Reads the next object from rdr, which must be a string or implement io.Reader,
+  and defaults to *in*.
+
+  opts is as in joker.edn/read-string.`, "1.8"))
+
+	ednNamespace.InternVar("read-string", read_string_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("s")), NewVectorFrom(MakeSymbol("opts"), MakeSymbol("s"))),
+			`Reads one object from the string s. Returns nil if s is blank
+  (that is, opts default to {:eof nil}).
+
+  opts is a map that can include the following keys:
+  :eof - value to return on end-of-file. When opts are supplied
+  without :eof, eof throws an error.
+  :readers - a map of tag symbols to data-reader functions to be considered
+  before default-data-readers. When not supplied, only the default-data-readers
+  (#inst and #uuid) will be used.
+  :default - a function of two args, that will, if present and no reader is found
+  for a tag, be called with the tag and the value.
+
+  Values nested deeper than 10000 levels cause an error.`, "1.8"))
+
+	ednNamespace.InternVar("write", write_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("wr"), MakeSymbol("v"))),
+			`Writes the edn representation of v to wr, which must be io.Writer
+  (for example, as returned by joker.os/create). See joker.edn/write-string.
+  Nothing is written if v cannot be represented in edn.`, "1.8"))
+
+	ednNamespace.InternVar("write-string", write_string_,
+		MakeMeta(
+			NewListFrom(NewVectorFrom(MakeSymbol("v"))),
+			`Returns the edn representation of v, which reads back as a value equal to v.
+  Throws an error if v contains values that cannot be represented in edn,
+  such as functions, atoms or records.
+  Times are written as #inst tagged literals.`, "1.8").Plus(MakeKeyword("tag"), String{S: "String"}))
+
+}
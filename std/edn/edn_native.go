@@ -0,0 +1,38 @@
+package edn
+
+import (
+	"bufio"
+	. "github.com/candid82/joker/core"
+	"io"
+	"strings"
+)
+
+// readString reads one object from s. As in clojure.edn,
+// nil opts mean {:eof nil}, so that blank s reads as nil.
+func readString(s string, opts Map) Object {
+	if opts == nil {
+		opts = EmptyArrayMap().Assoc(MakeKeyword("eof"), NIL).(Map)
+	}
+	return ReadEDN(strings.NewReader(s), opts)
+}
+
+func read(rdr Object, opts Map) Object {
+	if rdr == nil {
+		rdr, _, _ = GLOBAL_ENV.StdIO()
+	}
+	switch rdr := rdr.(type) {
+	case String:
+		return ReadEDN(strings.NewReader(rdr.S), opts)
+	case io.RuneReader:
+		return ReadEDN(rdr, opts)
+	case io.Reader:
+		return ReadEDN(bufio.NewReader(rdr), opts)
+	default:
+		panic(RT.NewError("rdr must be a string or io.Reader"))
+	}
+}
+
+func write(wr io.Writer, v Object) Object {
+	WriteEDN(wr, v)
+	return NIL
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -134,10 +135,12 @@ func mapToResp(response Map, w http.ResponseWriter) {
 }
 
 func sendRequest(request Map) Map {
-	req := mapToReq(request)
-	RT.GIL.Unlock()
-	resp, err := client.Do(req)
-	RT.GIL.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := mapToReq(request).WithContext(ctx)
+	var resp *http.Response
+	var err error
+	RT.RunBlocking(func() { resp, err = client.Do(req) }, cancel)
 	PanicOnErr(err)
 	return respToMap(resp)
 }
@@ -149,12 +152,14 @@ func startServer(addr string, handler Callable) Object {
 		host = MakeString(addr[:i])
 		port = MakeString(addr[i+1:])
 	}
-	RT.GIL.Unlock()
-	defer RT.GIL.Lock()
+	handlerRT := RT.Spawn()
+	rt := RT.ReleaseGIL()
+	defer rt.AcquireGIL()
 	err := http.ListenAndServe(addr, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		RT.GIL.Lock()
+		rt := handlerRT.Spawn()
+		rt.AcquireGIL()
 		defer func() {
-			RT.GIL.Unlock()
+			rt.ReleaseGIL()
 			if r := recover(); r != nil {
 				w.WriteHeader(500)
 				io.WriteString(w, "Internal server error")
@@ -10,17 +10,17 @@ import (
 	"io"
 )
 
-type UUID [16]byte
+type uuidBytes [16]byte
 
 var rander = rand.Reader // random function
 
-func (uuid UUID) String() string {
+func (uuid uuidBytes) String() string {
 	var buf [36]byte
 	encodeHex(buf[:], uuid)
 	return string(buf[:])
 }
 
-func encodeHex(dst []byte, uuid UUID) {
+func encodeHex(dst []byte, uuid uuidBytes) {
 	hex.Encode(dst, uuid[:4])
 	dst[8] = '-'
 	hex.Encode(dst[9:13], uuid[4:6])
@@ -33,7 +33,7 @@ func encodeHex(dst []byte, uuid UUID) {
 }
 
 func new() string {
-	var uuid UUID
+	var uuid uuidBytes
 	_, err := io.ReadFull(rander, uuid[:])
 	if err != nil {
 		panic(RT.NewError("Error generating UUID: " + err.Error()))
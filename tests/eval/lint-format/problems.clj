@@ -0,0 +1,10 @@
+(ns problems)
+
+(defn f
+  [x]
+  (let [y 1]
+    x))
+
+(f 1 2)
+
+(cond 1)
@@ -0,0 +1,4 @@
+(ns warnings)
+
+(let [x 1]
+  2)
@@ -0,0 +1,11 @@
+(ns app.a)
+
+(defn f [x y] (+ x y))
+
+(defn- secret [] 1)
+
+(defn g ([] 1) ([x] x))
+
+(defn- used [] (secret))
+
+(defn entry [] (used))
@@ -0,0 +1,14 @@
+(ns app.b
+  (:require [app.a :as a :refer [g missing-referred]]
+            [app.c :as c]
+            [joker.string :as s]))
+
+(defn h []
+  (a/f 1 2 3)
+  (c/k)
+  (c/k 1 2 3)
+  (g 1 2)
+  (a/missing)
+  (a/secret)
+  (c/nope)
+  (s/join []))
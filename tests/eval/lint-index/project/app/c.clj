@@ -0,0 +1,5 @@
+(ns app.c)
+
+(defn k [x & more] (cons x more))
+
+(defn l [] (app.b/h 1))
@@ -0,0 +1,10 @@
+(ns app.a)
+
+(defn f [x] x)
+
+(defn unused-fn [] 1)
+
+(defn- helper [] 2)
+
+#_{:joker/ignore [:private-var]}
+(defn g [] (helper))
@@ -0,0 +1,8 @@
+(ns app.b
+  (:require [app.a :as a]))
+
+(defn h []
+  (a/f 1 2)
+  #_{:joker/ignore [:private-var]}
+  (a/helper)
+  (a/unknown))
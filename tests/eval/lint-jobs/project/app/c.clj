@@ -0,0 +1,6 @@
+(ns app.c
+  (:require [app.a :as a]))
+
+(defn k []
+  (a/f)
+  (let [x 1]))
@@ -0,0 +1,6 @@
+(ns app.extra.d)
+
+(defn dup [] 1)
+
+(defn uses-unrequired []
+  (app.c/k))
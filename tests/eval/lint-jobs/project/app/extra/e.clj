@@ -0,0 +1,6 @@
+(ns app.extra.d)
+
+(defn dup [] 2)
+
+(defn uses-missing []
+  (app.missing/thing))
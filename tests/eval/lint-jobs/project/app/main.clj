@@ -0,0 +1,7 @@
+(ns app.main
+  (:require [app.b :as b]
+            [app.c :as c]))
+
+(defn -main []
+  (b/h)
+  (c/k))
@@ -0,0 +1,3 @@
+(ns a)
+
+(defn f [] (let [z 1] nil))
@@ -0,0 +1,3 @@
+(ns b)
+
+(let [y 2] nil)
@@ -0,0 +1,3 @@
+(ns c)
+
+(defn g [] (undefined-fn))
@@ -0,0 +1,10 @@
+(ns go-loop.core
+  (:require [clojure.core.async :as async :refer [go-loop]]))
+
+(go-loop [i 0]
+  (when (< i 3)
+    (recur (inc i))))
+
+(async/go-loop [i 0]
+  (when (< i 3)
+    (recur (inc i))))
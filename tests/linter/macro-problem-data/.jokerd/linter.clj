@@ -0,0 +1,9 @@
+(ns checks.core)
+
+(defmacro string-rule [& body]
+  (joker.core/println-linter__ (ex-info "Rule isn't a keyword" {:form &form :_prefix "Parse warning" :_rule "string-rule"}))
+  (first body))
+
+(defmacro no-data [& body]
+  (joker.core/println-linter__ (ex-info "No data" nil))
+  (first body))
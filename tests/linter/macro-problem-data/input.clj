@@ -0,0 +1,6 @@
+(ns macro-problem-data.core
+  (:require [checks.core :as c]))
+
+(c/string-rule 1)
+
+(c/no-data 2)
@@ -0,0 +1,17 @@
+(ns rule-severities)
+
+(defn f
+  [x]
+  (let [y 1]
+    x))
+
+(f 1 2)
+
+(if (f 1) 2)
+
+(-> 1)
+
+(try (f 1))
+
+(let [{:keys [a]} {}]
+  1)
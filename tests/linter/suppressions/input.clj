@@ -0,0 +1,27 @@
+(ns suppressions)
+
+#_{:joker/ignore [:unused-binding]}
+(defn f
+  [x]
+  (let [y 1]
+    x))
+
+(defn g
+  [x]
+  ^{:joker/ignore :wrong-arity} (f 1 2)
+  (let [z 1]
+    x))
+
+#_{:joker/ignore [:redundant-do :unused-binding]}
+(defn h
+  [x]
+  (let [w 1]
+    x))
+
+(let [{:keys [a]} {}]
+  #_{:joker/ignore true} (f 1 2 3))
+
+#_{:joker/ignore true}
+(g 1)
+
+(f 1 2)